@@ -4,18 +4,41 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"mime"
 	"net/http"
 	"net/http/cookiejar"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pquerna/otp/totp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type client struct {
 	httpClient  *http.Client
 	endpointUrl string
+	retryPolicy RetryPolicy
+	rateLimiter *rateLimiter
+	logger      *slog.Logger
+	tracer      trace.Tracer
+	metrics     Metrics
+
+	// Credentials of the last login, which are used to log in again when the session expired.
+	username     string
+	password     string
+	sharedSecret string
+
+	// sessionMu guards the session generation, which is incremented on every login, so that
+	// concurrent calls do not log in again if another call already renewed the session.
+	sessionMu         sync.Mutex
+	sessionGeneration int
 }
 
 type response struct {
@@ -26,13 +49,6 @@ type response struct {
 	ResponseData any    `json:"resData"`
 }
 
-type errorResponse struct {
-	Code       int    `json:"code"`
-	Message    string `json:"msg"`
-	ReasonCode string `json:"reasonCode"`
-	Reason     string `json:"reason"`
-}
-
 type nameserverInfoRequest struct {
 	Domain   string `json:"domain,omitempty"`
 	Name     string `json:"name,omitempty"`
@@ -57,6 +73,7 @@ type nameserverCreateRecordRequest struct {
 	Content  string `json:"content"`
 	TTL      int    `json:"ttl"`
 	Priority uint   `json:"prio"`
+	urlRedirect
 }
 
 type nameserverCreateRecordResponse struct {
@@ -70,12 +87,27 @@ type nameserverUpdateRecordRequest struct {
 	Content  string `json:"content"`
 	TTL      int    `json:"ttl"`
 	Priority uint   `json:"prio"`
+	*urlRedirectUpdate
+}
+
+type nameserverUpdateRecordsRequest struct {
+	IDs      []string `json:"id"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Content  string   `json:"content"`
+	TTL      int      `json:"ttl"`
+	Priority uint     `json:"prio"`
+	*urlRedirectUpdate
 }
 
 type nameserverDeleteRecordRequest struct {
 	ID string `json:"id"`
 }
 
+type nameserverDeleteRecordsRequest struct {
+	IDs []string `json:"id"`
+}
+
 type nameserverRecord struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
@@ -83,18 +115,102 @@ type nameserverRecord struct {
 	Content  string `json:"content"`
 	TTL      int    `json:"ttl"`
 	Priority uint   `json:"prio"`
+	urlRedirect
+}
+
+// urlRedirect contains the options of the web forwarding records URL and FRAME. They are empty for
+// all other record types.
+type urlRedirect struct {
+	RedirectType        string `json:"urlRedirectType,omitempty"`
+	RedirectTitle       string `json:"urlRedirectTitle,omitempty"`
+	RedirectDescription string `json:"urlRedirectDescription,omitempty"`
+	RedirectKeywords    string `json:"urlRedirectKeywords,omitempty"`
+	RedirectFavIcon     string `json:"urlRedirectFavIcon,omitempty"`
+	Append              bool   `json:"urlAppend,omitempty"`
+}
+
+// urlRedirectUpdate contains the options of web forwarding records when they are updated. Unlike in
+// urlRedirect, empty options are sent as well, so that options which are not set anymore are
+// cleared. An empty redirect type is omitted, so that the current type is kept.
+type urlRedirectUpdate struct {
+	RedirectType        string `json:"urlRedirectType,omitempty"`
+	RedirectTitle       string `json:"urlRedirectTitle"`
+	RedirectDescription string `json:"urlRedirectDescription"`
+	RedirectKeywords    string `json:"urlRedirectKeywords"`
+	RedirectFavIcon     string `json:"urlRedirectFavIcon"`
+	Append              bool   `json:"urlAppend"`
+}
+
+// update returns the options which are sent when the record is updated, or nil if it is not a web
+// forwarding record.
+func (r nameserverRecord) update() *urlRedirectUpdate {
+	if !isForwardingType(r.Type) {
+		return nil
+	}
+
+	update := urlRedirectUpdate(r.urlRedirect)
+
+	return &update
 }
 
 type nameserverCreateRequest struct {
-	Domain string   `json:"domain"`
-	Type   string   `json:"type"`
-	NS     []string `json:"ns"`
+	Domain   string   `json:"domain"`
+	Type     string   `json:"type"`
+	NS       []string `json:"ns,omitempty"`
+	MasterIP string   `json:"masterIp,omitempty"`
+	SOAEmail string   `json:"soaEmail,omitempty"`
+	Web      string   `json:"web,omitempty"`
+	Mail     string   `json:"mail,omitempty"`
+}
+
+type nameserverUpdateRequest struct {
+	Domain   string `json:"domain"`
+	MasterIP string `json:"masterIp,omitempty"`
+}
+
+type nameserverTransferRequest struct {
+	Domain string `json:"domain"`
 }
 
 type nameserverDeleteRequest struct {
 	Domain string `json:"domain"`
 }
 
+type dnssecInfoRequest struct {
+	Domains []string `json:"domains"`
+}
+
+type dnssecInfoResponse struct {
+	Data []dnssecInfoItem `json:"data"`
+}
+
+type dnssecInfoItem struct {
+	Domain   string `json:"domain"`
+	KeyCount int    `json:"keyCount"`
+	Status   string `json:"dnssecStatus"`
+}
+
+type dnssecDomainRequest struct {
+	DomainName string `json:"domainName"`
+}
+
+type dnssecListKeysResponse struct {
+	Keys []dnssecKey `json:"dnskey"`
+}
+
+type dnssecKey struct {
+	ID           string `json:"id"`
+	OwnerName    string `json:"ownerName"`
+	KeyTag       uint16 `json:"keyTag"`
+	FlagID       uint16 `json:"flagId"`
+	AlgorithmID  uint8  `json:"algorithmId"`
+	PublicKey    string `json:"publicKey"`
+	DigestTypeID uint8  `json:"digestTypeId"`
+	Digest       string `json:"digest"`
+	Status       string `json:"status"`
+	Active       bool   `json:"active"`
+}
+
 type accountLoginRequest struct {
 	User string `json:"user"`
 	Pass string `json:"pass"`
@@ -109,8 +225,9 @@ type accountUnlockRequest struct {
 }
 
 type nameserverListRequest struct {
-	Page      int `json:"page,omitempty"`
-	PageLimit int `json:"pagelimit,omitempty"`
+	Domain    string `json:"domain,omitempty"`
+	Page      int    `json:"page,omitempty"`
+	PageLimit int    `json:"pagelimit,omitempty"`
 }
 
 type nameserverListResponse struct {
@@ -119,71 +236,104 @@ type nameserverListResponse struct {
 }
 
 type nameserverListItem struct {
-	RoID   int    `json:"roId"`
-	Domain string `json:"domain"`
-	Type   string `json:"type"`
+	RoID     int    `json:"roId"`
+	Domain   string `json:"domain"`
+	Type     string `json:"type"`
+	MasterIP string `json:"masterIp"`
+}
+
+// clientOptions configures optional behavior of a client.
+type clientOptions struct {
+	// HTTP client to send requests with, or nil to use a default client. It is copied, so that a
+	// separate cookie jar can be installed for the session.
+	httpClient *http.Client
+
+	retryPolicy RetryPolicy
+
+	// Rate limiter for all requests, or nil if requests are not limited.
+	rateLimiter *rateLimiter
+
+	// Logger for all calls, or nil if calls are not logged.
+	logger *slog.Logger
+
+	// Tracer for spans of all calls, or nil if calls are not traced.
+	tracer trace.Tracer
+
+	// Metrics for all calls, or nil if no metrics are collected.
+	metrics Metrics
 }
 
 const endpointURL = "https://api.domrobot.com/jsonrpc/"
 
-func newClient(endpointURL string) (*client, error) {
+const defaultHTTPTimeout = 30 * time.Second
+
+// Maximum size of a response body, which is far beyond the size of the largest zones.
+const maxResponseSize = 16 << 20
+
+func newClient(endpointURL string, options clientOptions) (*client, error) {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		return nil, err
 	}
 
-	httpClient := &http.Client{
-		Transport: &http.Transport{DisableCompression: true},
-		Jar:       jar,
+	var httpClient *http.Client
+
+	if options.httpClient != nil {
+		httpClientCopy := *options.httpClient
+		httpClient = &httpClientCopy
+	} else {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DisableCompression = true
+
+		httpClient = &http.Client{
+			Transport: transport,
+			Timeout:   defaultHTTPTimeout,
+		}
 	}
 
-	return &client{httpClient, endpointURL}, nil
-}
+	// The session is tracked by a cookie, so every client needs its own cookie jar.
+	httpClient.Jar = jar
 
-func (c *client) getRecords(ctx context.Context, domain string) ([]nameserverRecord, error) {
-	response, err := c.call(ctx, "nameserver.info", nameserverInfoRequest{
-		Domain: domain,
-	})
+	tracer := options.tracer
 
-	if err != nil {
-		return nil, err
+	if tracer == nil {
+		tracer = noop.NewTracerProvider().Tracer(tracerName)
 	}
 
-	data := nameserverInfoResponse{}
-	err = json.Unmarshal(response, &data)
+	metrics := options.metrics
 
-	if err != nil {
-		return nil, err
+	if metrics == nil {
+		metrics = noopMetrics{}
 	}
 
-	return data.Records, nil
+	return &client{
+		httpClient:  httpClient,
+		endpointUrl: endpointURL,
+		retryPolicy: options.retryPolicy.withDefaults(),
+		rateLimiter: options.rateLimiter,
+		logger:      options.logger,
+		tracer:      tracer,
+		metrics:     metrics,
+	}, nil
 }
 
-func (c *client) findRecords(ctx context.Context, record nameserverRecord, domain string, matchContent bool) ([]nameserverRecord, error) {
-	request := nameserverInfoRequest{
+func (c *client) getRecords(ctx context.Context, domain string) (*nameserverInfoResponse, error) {
+	response, err := c.call(ctx, "nameserver.info", nameserverInfoRequest{
 		Domain: domain,
-		Type:   record.Type,
-		Name:   record.Name,
-	}
-
-	if matchContent {
-		request.Content = record.Content
-	}
-
-	response, err := c.call(ctx, "nameserver.info", request)
+	})
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get records of %s: %w", domain, err)
 	}
 
 	data := nameserverInfoResponse{}
 	err = json.Unmarshal(response, &data)
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to parse records of %s: %w", domain, err)
 	}
 
-	return data.Records, nil
+	return &data, nil
 }
 
 func (c *client) createRecord(ctx context.Context, record nameserverRecord, domain string) (string, error) {
@@ -194,6 +344,8 @@ func (c *client) createRecord(ctx context.Context, record nameserverRecord, doma
 		Content:  record.Content,
 		TTL:      ensureMinTTL(record.TTL),
 		Priority: record.Priority,
+
+		urlRedirect: record.urlRedirect,
 	})
 
 	if err != nil {
@@ -222,6 +374,8 @@ func (c *client) updateRecord(ctx context.Context, record nameserverRecord) erro
 		Content:  record.Content,
 		TTL:      ensureMinTTL(record.TTL),
 		Priority: record.Priority,
+
+		urlRedirectUpdate: record.update(),
 	})
 
 	if err != nil {
@@ -243,14 +397,55 @@ func (c *client) deleteRecord(ctx context.Context, record nameserverRecord) erro
 	return nil
 }
 
-func (c *client) createNameserver(ctx context.Context, domain string, _type string, nameservers []string) error {
-	_, err := c.call(ctx, "nameserver.create", nameserverCreateRequest{
-		Domain: domain,
-		Type:   _type,
-		NS:     nameservers,
+// updateRecords sets the values of the record on all records with the given IDs at once.
+func (c *client) updateRecords(ctx context.Context, ids []string, record nameserverRecord) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := c.call(ctx, "nameserver.updateRecord", nameserverUpdateRecordsRequest{
+		IDs:      ids,
+		Name:     record.Name,
+		Type:     record.Type,
+		Content:  record.Content,
+		TTL:      ensureMinTTL(record.TTL),
+		Priority: record.Priority,
+
+		urlRedirectUpdate: record.update(),
 	})
 
-	return err
+	if err != nil {
+		return fmt.Errorf("failed to update %d records %s %s: %w", len(ids), record.Name, record.Type, err)
+	}
+
+	return nil
+}
+
+// deleteRecords deletes all records with the given IDs at once.
+func (c *client) deleteRecords(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := c.call(ctx, "nameserver.deleteRecord", nameserverDeleteRecordsRequest{
+		IDs: ids,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to delete %d records: %w", len(ids), err)
+	}
+
+	return nil
+}
+
+func (c *client) createNameserver(ctx context.Context, request nameserverCreateRequest) error {
+	_, err := c.call(ctx, "nameserver.create", request)
+
+	if err != nil {
+		return fmt.Errorf("failed to create nameserver %s: %w", request.Domain, err)
+	}
+
+	return nil
 }
 
 func (c *client) deleteNameserver(ctx context.Context, domain string) error {
@@ -258,29 +453,58 @@ func (c *client) deleteNameserver(ctx context.Context, domain string) error {
 		Domain: domain,
 	})
 
-	return err
+	if err != nil {
+		return fmt.Errorf("failed to delete nameserver %s: %w", domain, err)
+	}
+
+	return nil
+}
+
+func (c *client) updateNameserver(ctx context.Context, request nameserverUpdateRequest) error {
+	_, err := c.call(ctx, "nameserver.update", request)
+
+	if err != nil {
+		return fmt.Errorf("failed to update nameserver %s: %w", request.Domain, err)
+	}
+
+	return nil
+}
+
+func (c *client) transferNameserver(ctx context.Context, domain string) error {
+	_, err := c.call(ctx, "nameserver.transfer", nameserverTransferRequest{
+		Domain: domain,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to transfer nameserver %s: %w", domain, err)
+	}
+
+	return nil
 }
 
-func (c *client) listNameservers(ctx context.Context) ([]nameserverListItem, error) {
+// listNameservers lists the nameserver domains matching the pattern, in which * matches any
+// characters. All domains are listed if the pattern is empty.
+func (c *client) listNameservers(ctx context.Context, pattern string) ([]nameserverListItem, error) {
 	var allDomains []nameserverListItem
 	page := 1
 	pageLimit := 100
 
 	for {
 		response, err := c.call(ctx, "nameserver.list", nameserverListRequest{
+			Domain:    pattern,
 			Page:      page,
 			PageLimit: pageLimit,
 		})
 
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to list nameservers: %w", err)
 		}
 
 		data := nameserverListResponse{}
 		err = json.Unmarshal(response, &data)
 
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to parse nameserver list: %w", err)
 		}
 
 		allDomains = append(allDomains, data.Domains...)
@@ -295,101 +519,282 @@ func (c *client) listNameservers(ctx context.Context) ([]nameserverListItem, err
 	return allDomains, nil
 }
 
+func (c *client) getDNSSECInfo(ctx context.Context, domains []string) ([]dnssecInfoItem, error) {
+	response, err := c.call(ctx, "dnssec.info", dnssecInfoRequest{
+		Domains: domains,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DNSSEC information: %w", err)
+	}
+
+	data := dnssecInfoResponse{}
+	err = json.Unmarshal(response, &data)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DNSSEC information: %w", err)
+	}
+
+	return data.Data, nil
+}
+
+// callDNSSEC calls the DNSSEC method which only takes the domain as parameter.
+func (c *client) callDNSSEC(ctx context.Context, method string, domain string) error {
+	_, err := c.call(ctx, method, dnssecDomainRequest{
+		DomainName: domain,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to call %s for %s: %w", method, domain, err)
+	}
+
+	return nil
+}
+
+func (c *client) listDNSSECKeys(ctx context.Context, domain string) ([]dnssecKey, error) {
+	response, err := c.call(ctx, "dnssec.listKeys", dnssecDomainRequest{
+		DomainName: domain,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DNSSEC keys of %s: %w", domain, err)
+	}
+
+	data := dnssecListKeysResponse{}
+	err = json.Unmarshal(response, &data)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse DNSSEC keys of %s: %w", domain, err)
+	}
+
+	return data.Keys, nil
+}
+
 func (c *client) login(ctx context.Context, username string, password string, sharedSecret string) error {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	c.username = username
+	c.password = password
+	c.sharedSecret = sharedSecret
+
+	return c.authenticate(ctx)
+}
+
+// authenticate logs in with the stored credentials and unlocks the session with a TAN if
+// two-factor authentication is enabled. The caller must hold sessionMu.
+func (c *client) authenticate(ctx context.Context) (err error) {
+	defer func() { c.metrics.ObserveLogin(err) }()
+
 	response, err := c.call(ctx, "account.login", accountLoginRequest{
-		User: username,
-		Pass: password,
+		User: c.username,
+		Pass: c.password,
 	})
 
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to log in as %s: %w", c.username, err)
 	}
 
 	data := accountLoginResponse{}
 	err = json.Unmarshal(response, &data)
 
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to parse login response: %w", err)
 	}
 
 	if data.TFA == "GOOGLE-AUTH" {
-		tan, err := totp.GenerateCode(sharedSecret, time.Now())
+		tan, err := totp.GenerateCode(c.sharedSecret, time.Now())
 
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to generate TAN: %w", err)
 		}
 
-		err = c.unlock(ctx, tan)
+		_, err = c.call(ctx, "account.unlock", accountUnlockRequest{
+			TAN: tan,
+		})
 
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to unlock account with TAN: %w", err)
 		}
 	}
 
+	c.sessionGeneration++
+
 	return nil
 }
 
+// reauthenticate logs in again after the session of the given generation expired. If another call
+// already renewed the session in the meantime, it returns without logging in.
+func (c *client) reauthenticate(ctx context.Context, generation int) error {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	if c.sessionGeneration != generation {
+		return nil
+	}
+
+	return c.authenticate(ctx)
+}
+
+func (c *client) getSessionGeneration() int {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+
+	return c.sessionGeneration
+}
+
 func (c *client) logout(ctx context.Context) error {
 	_, err := c.call(ctx, "account.logout", nil)
 
 	return err
 }
 
-func (c *client) unlock(ctx context.Context, tan string) error {
-	_, err := c.call(ctx, "account.unlock", accountUnlockRequest{
-		TAN: tan,
-	})
+// call executes the JSON-RPC method and retries it after transient failures according to the
+// retry policy.
+func (c *client) call(ctx context.Context, method string, params any) (_ []byte, err error) {
+	ctx, span := c.startCallSpan(ctx, method)
+	defer func() { endSpan(span, err) }()
 
-	return err
+	executed := false
+
+	for attempt := 1; ; attempt++ {
+		responseData, err := c.callAuthenticated(ctx, method, params)
+
+		if executed && isAlreadyExecuted(method, err) {
+			// An earlier attempt has been executed, but its response has been lost.
+			return nil, nil
+		}
+
+		if err == nil || attempt >= c.retryPolicy.MaxAttempts || !c.retryPolicy.isRetryable(method, err) {
+			return responseData, err
+		}
+
+		executed = executed || mayHaveBeenExecuted(err)
+
+		c.metrics.ObserveRetry(method)
+		span.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", attempt), attribute.String("error", err.Error())))
+
+		if waitErr := c.retryPolicy.wait(ctx, attempt); waitErr != nil {
+			return nil, fmt.Errorf("%w (last error: %v)", waitErr, err)
+		}
+	}
+}
+
+// callAuthenticated executes the JSON-RPC method. If the session has expired, it logs in again and
+// replays the method once.
+func (c *client) callAuthenticated(ctx context.Context, method string, params any) ([]byte, error) {
+	if strings.HasPrefix(method, "account.") {
+		return c.do(ctx, method, params)
+	}
+
+	generation := c.getSessionGeneration()
+	responseData, err := c.do(ctx, method, params)
+
+	if err == nil || !isSessionExpired(err) {
+		return responseData, err
+	}
+
+	err = c.reauthenticate(ctx, generation)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in again after the session expired: %w", err)
+	}
+
+	return c.do(ctx, method, params)
+}
+
+// do executes a single JSON-RPC request and logs it.
+func (c *client) do(ctx context.Context, method string, params any) ([]byte, error) {
+	start := time.Now()
+	responseData, code, err := c.send(ctx, method, params)
+	duration := time.Since(start)
+
+	c.logCall(ctx, method, params, code, duration, err)
+	c.metrics.ObserveCall(method, code, duration)
+
+	if code != 0 {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int("inwx.code", code))
+	}
+
+	return responseData, err
 }
 
-func (c *client) call(ctx context.Context, method string, params any) ([]byte, error) {
+// send executes a single JSON-RPC request. It returns the result code, or zero if no valid
+// JSON-RPC response has been received.
+func (c *client) send(ctx context.Context, method string, params any) ([]byte, int, error) {
 	requestBody := map[string]interface{}{}
 	requestBody["method"] = method
 	requestBody["params"] = params
 	requestJsonBody, err := json.Marshal(requestBody)
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	if c.rateLimiter != nil {
+		err = c.rateLimiter.wait(ctx)
+
+		if err != nil {
+			return nil, 0, err
+		}
 	}
 
 	httpRequest, err := http.NewRequestWithContext(ctx, "POST", c.endpointUrl, bytes.NewReader(requestJsonBody))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	httpRequest.Header.Set("content-type", "application/json; charset=UTF-8")
 
 	httpResponse, err := c.httpClient.Do(httpRequest)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	responseBody, err := io.ReadAll(httpResponse.Body)
+	defer httpResponse.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(httpResponse.Body, maxResponseSize+1))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	if len(responseBody) > maxResponseSize {
+		return nil, 0, newHTTPError(httpResponse, responseBody, fmt.Errorf("response exceeds %d bytes", maxResponseSize))
+	}
+
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode > 299 {
+		return nil, 0, newHTTPError(httpResponse, responseBody, nil)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(httpResponse.Header.Get("content-type"))
+	if err != nil || !strings.HasSuffix(mediaType, "json") {
+		return nil, 0, newHTTPError(httpResponse, responseBody, fmt.Errorf("unexpected content type %q", httpResponse.Header.Get("content-type")))
 	}
 
 	var response response
 	err = json.Unmarshal(responseBody, &response)
 	if err != nil {
-		return nil, err
+		return nil, 0, newHTTPError(httpResponse, responseBody, err)
 	}
 
 	responseData, err := json.Marshal(response.ResponseData)
 	if err != nil {
-		return nil, err
+		return nil, response.Code, err
 	}
 
-	return responseData, checkResponse(response)
+	return responseData, response.Code, checkResponse(response)
 }
 
-func (r *errorResponse) Error() string {
-	if r.Reason != "" {
-		return fmt.Sprintf("(%d) %s. Reason: (%s) %s", r.Code, r.Message, r.ReasonCode, r.Reason)
+// isSessionExpired reports whether the error indicates that the session is not authenticated
+// anymore, for example because it timed out on the INWX side.
+func isSessionExpired(err error) bool {
+	var apiError *APIError
+
+	if !errors.As(err, &apiError) {
+		return false
 	}
 
-	return fmt.Sprintf("(%d) %s", r.Code, r.Message)
+	return apiError.Code == 2200 || apiError.Code == 2501
 }
 
 func checkResponse(r response) error {
@@ -397,7 +802,7 @@ func checkResponse(r response) error {
 		return nil
 	}
 
-	return &errorResponse{
+	return &APIError{
 		Code:       r.Code,
 		Message:    r.Message,
 		Reason:     r.Reason,
@@ -0,0 +1,194 @@
+package inwx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// DNSSECStatus contains the DNSSEC status of a zone at INWX.
+type DNSSECStatus struct {
+	// Whether DNSSEC is enabled for the zone.
+	Enabled bool
+
+	// Status as reported by INWX, or an empty string if DNSSEC is not enabled.
+	Status string
+
+	// Number of keys of the zone.
+	KeyCount int
+}
+
+// DNSSECKey is a DNSSEC key of a zone at INWX.
+type DNSSECKey struct {
+	// ID of the key at INWX.
+	ID string
+
+	KeyTag    uint16
+	Flags     uint16
+	Algorithm uint8
+	PublicKey string
+
+	// Digest type and digest of the DS record of the key. They are only set for key signing keys.
+	DigestType uint8
+	Digest     string
+
+	// Status of the key as reported by INWX.
+	Status string
+
+	// Whether the key is used to sign the zone.
+	Active bool
+}
+
+// IsKSK reports whether the key is a key signing key, whose DS record is published in the parent
+// zone.
+func (k DNSSECKey) IsKSK() bool {
+	return k.Flags&1 == 1
+}
+
+// DNSKEY returns the DNSKEY record of the key at the apex of the zone.
+func (k DNSSECKey) DNSKEY() libdns.RR {
+	return libdns.RR{
+		Name: "@",
+		Type: "DNSKEY",
+		Data: fmt.Sprintf("%d 3 %d %s", k.Flags, k.Algorithm, k.PublicKey),
+	}
+}
+
+// DS returns the DS record of the key, which is published in the parent zone. It is only valid for
+// key signing keys.
+func (k DNSSECKey) DS() libdns.RR {
+	return libdns.RR{
+		Name: "@",
+		Type: "DS",
+		Data: fmt.Sprintf("%d %d %d %s", k.KeyTag, k.Algorithm, k.DigestType, k.Digest),
+	}
+}
+
+// EnableDNSSEC enables DNSSEC for the zone. INWX creates the keys, signs the zone and publishes
+// the DS records at the registry if the domain is registered at INWX.
+func (p *Provider) EnableDNSSEC(ctx context.Context, zone string) (err error) {
+	return p.callDNSSEC(ctx, "EnableDNSSEC", "dnssec.enableDNSSEC", zone)
+}
+
+// DisableDNSSEC disables DNSSEC for the zone and deletes its keys.
+func (p *Provider) DisableDNSSEC(ctx context.Context, zone string) (err error) {
+	return p.callDNSSEC(ctx, "DisableDNSSEC", "dnssec.disableDNSSEC", zone)
+}
+
+// RolloverDNSSECKeys replaces the keys of the zone with new keys. The DS records of the new key
+// signing keys must be published in the parent zone, which INWX does itself if the domain is
+// registered at INWX.
+func (p *Provider) RolloverDNSSECKeys(ctx context.Context, zone string) (err error) {
+	return p.callDNSSEC(ctx, "RolloverDNSSECKeys", "dnssec.keyRollover", zone)
+}
+
+// GetDNSSECStatus returns the DNSSEC status of the zone.
+func (p *Provider) GetDNSSECStatus(ctx context.Context, zone string) (_ DNSSECStatus, err error) {
+	ctx, span := p.startSpan(ctx, "GetDNSSECStatus", zone)
+	defer func() { endSpan(span, err) }()
+
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		return DNSSECStatus{}, err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	items, err := client.getDNSSECInfo(ctx, []string{getDomain(zone)})
+
+	if err != nil {
+		return DNSSECStatus{}, err
+	}
+
+	for _, item := range items {
+		if strings.EqualFold(item.Domain, getDomain(zone)) {
+			return DNSSECStatus{
+				Enabled:  item.Status != "",
+				Status:   item.Status,
+				KeyCount: item.KeyCount,
+			}, nil
+		}
+	}
+
+	return DNSSECStatus{}, nil
+}
+
+// ListDNSSECKeys lists the DNSSEC keys of the zone.
+func (p *Provider) ListDNSSECKeys(ctx context.Context, zone string) (_ []DNSSECKey, err error) {
+	ctx, span := p.startSpan(ctx, "ListDNSSECKeys", zone)
+	defer func() { endSpan(span, err) }()
+
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	items, err := client.listDNSSECKeys(ctx, getDomain(zone))
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]DNSSECKey, 0, len(items))
+
+	for _, item := range items {
+		keys = append(keys, DNSSECKey{
+			ID:         item.ID,
+			KeyTag:     item.KeyTag,
+			Flags:      item.FlagID,
+			Algorithm:  item.AlgorithmID,
+			PublicKey:  item.PublicKey,
+			DigestType: item.DigestTypeID,
+			Digest:     item.Digest,
+			Status:     item.Status,
+			Active:     item.Active,
+		})
+	}
+
+	return keys, nil
+}
+
+// ListDSRecords returns the DS records of the active key signing keys of the zone, which have to
+// be published in the parent zone.
+func (p *Provider) ListDSRecords(ctx context.Context, zone string) (_ []libdns.RR, err error) {
+	ctx, span := p.startSpan(ctx, "ListDSRecords", zone)
+	defer func() { endSpan(span, err) }()
+
+	keys, err := p.ListDNSSECKeys(ctx, zone)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var records []libdns.RR
+
+	for _, key := range keys {
+		if key.IsKSK() && key.Active && key.Digest != "" {
+			records = append(records, key.DS())
+		}
+	}
+
+	return records, nil
+}
+
+// callDNSSEC performs the operation by calling the DNSSEC method for the zone.
+func (p *Provider) callDNSSEC(ctx context.Context, operation string, method string, zone string) (err error) {
+	ctx, span := p.startSpan(ctx, operation, zone)
+	defer func() { endSpan(span, err) }()
+
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	return client.callDNSSEC(ctx, method, getDomain(zone))
+}
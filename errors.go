@@ -0,0 +1,131 @@
+package inwx
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Sentinel errors for INWX result codes, which can be matched against an [APIError] with
+// [errors.Is].
+var (
+	// ErrObjectNotFound is matched by result code 2303 (object does not exist).
+	ErrObjectNotFound = errors.New("inwx: object does not exist")
+
+	// ErrObjectExists is matched by result code 2302 (object exists).
+	ErrObjectExists = errors.New("inwx: object exists")
+
+	// ErrParameterPolicy is matched by result code 2306 (parameter value policy error).
+	ErrParameterPolicy = errors.New("inwx: parameter value policy error")
+
+	// ErrAuthFailed is matched by result codes 2200 (authentication error), 2202 (invalid
+	// authorization information) and 2501 (authentication error; server closing connection).
+	ErrAuthFailed = errors.New("inwx: authentication failed")
+
+	// ErrRateLimited is matched by result code 2502 (session limit exceeded; server closing
+	// connection).
+	ErrRateLimited = errors.New("inwx: rate limit exceeded")
+)
+
+// ErrRolledBack is wrapped by the error of a failed operation in atomic mode if all changes that
+// had already been applied have been rolled back, so that the zone is in its previous state.
+var ErrRolledBack = errors.New("inwx: all changes have been rolled back")
+
+// ErrSlaveZone is wrapped by the error of an operation that modifies the records of a slave zone,
+// whose records are transferred from its master nameservers instead.
+var ErrSlaveZone = errors.New("inwx: records of slave zones cannot be modified")
+
+var resultCodeErrors = map[int]error{
+	2200: ErrAuthFailed,
+	2202: ErrAuthFailed,
+	2302: ErrObjectExists,
+	2303: ErrObjectNotFound,
+	2306: ErrParameterPolicy,
+	2501: ErrAuthFailed,
+	2502: ErrRateLimited,
+}
+
+// APIError is returned when INWX responds with a result code that indicates a failure.
+// https://www.inwx.de/en/help/apidoc
+type APIError struct {
+	// Result code of the response, e.g. 2303.
+	Code int
+
+	// Message describing the result code.
+	Message string
+
+	// Optional code of the reason for the failure.
+	ReasonCode string
+
+	// Optional description of the reason for the failure.
+	Reason string
+}
+
+func (e *APIError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("(%d) %s. Reason: (%s) %s", e.Code, e.Message, e.ReasonCode, e.Reason)
+	}
+
+	return fmt.Sprintf("(%d) %s", e.Code, e.Message)
+}
+
+// Is reports whether the result code of the error corresponds to the target sentinel error.
+func (e *APIError) Is(target error) bool {
+	err, ok := resultCodeErrors[e.Code]
+
+	return ok && err == target
+}
+
+// Maximum number of bytes of the response body included in an [HTTPError].
+const httpErrorBodySize = 512
+
+// HTTPError is returned when the API responds with an HTTP error status or with a body that is not
+// a valid JSON-RPC response, for example a maintenance page or an error page of a proxy.
+type HTTPError struct {
+	// HTTP status code of the response.
+	StatusCode int
+
+	// Content type of the response.
+	ContentType string
+
+	// Beginning of the response body, truncated to 512 bytes.
+	Body string
+
+	// Optional error that occurred while processing the response.
+	Err error
+}
+
+func newHTTPError(response *http.Response, body []byte, err error) *HTTPError {
+	snippet := body
+
+	if len(snippet) > httpErrorBodySize {
+		snippet = snippet[:httpErrorBodySize]
+	}
+
+	return &HTTPError{
+		StatusCode:  response.StatusCode,
+		ContentType: response.Header.Get("content-type"),
+		Body:        strings.ToValidUTF8(string(snippet), string(utf8.RuneError)),
+		Err:         err,
+	}
+}
+
+func (e *HTTPError) Error() string {
+	message := fmt.Sprintf("unexpected HTTP response %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+
+	if e.Body != "" {
+		message += fmt.Sprintf(" (body: %q)", e.Body)
+	}
+
+	return message
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
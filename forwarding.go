@@ -0,0 +1,147 @@
+package inwx
+
+import (
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// RedirectType is the kind of HTTP redirect of a [URL] record.
+type RedirectType string
+
+const (
+	// RedirectPermanent redirects with status 301 Moved Permanently.
+	RedirectPermanent RedirectType = "HEADER301"
+
+	// RedirectTemporary redirects with status 302 Found.
+	RedirectTemporary RedirectType = "HEADER302"
+)
+
+// redirectTypeFrame is the redirect type of FRAME records.
+const redirectTypeFrame = "FRAME"
+
+// URL is an INWX web forwarding record, which redirects HTTP requests for the name to the target.
+// It is not a DNS record type; INWX points the name to its forwarding servers instead.
+type URL struct {
+	Name string
+	TTL  time.Duration
+
+	// URL to which requests are redirected.
+	Target string
+
+	// Kind of the redirect. If it is empty, the default of INWX applies.
+	RedirectType RedirectType
+
+	// Whether the path of the request is appended to the target.
+	AppendPath bool
+
+	// Optional custom data associated with the provider serving this record. Records returned by
+	// the provider carry a [ProviderData] value.
+	ProviderData any
+}
+
+// RR returns the record as a [libdns.RR] with the target as data. The redirect options are not
+// part of the data.
+func (u URL) RR() libdns.RR {
+	return libdns.RR{
+		Name: u.Name,
+		TTL:  u.TTL,
+		Type: "URL",
+		Data: u.Target,
+	}
+}
+
+// Frame is an INWX web forwarding record, which shows the target in a frame, so that the name
+// stays visible in the address bar of the browser. It is not a DNS record type; INWX points the
+// name to its forwarding servers instead.
+type Frame struct {
+	Name string
+	TTL  time.Duration
+
+	// URL which is shown in the frame.
+	Target string
+
+	// Title of the page containing the frame.
+	Title string
+
+	// Description of the page containing the frame, which is set as meta tag.
+	Description string
+
+	// Keywords of the page containing the frame, which are set as meta tag.
+	Keywords string
+
+	// URL of the favicon of the page containing the frame.
+	FavIcon string
+
+	// Optional custom data associated with the provider serving this record. Records returned by
+	// the provider carry a [ProviderData] value.
+	ProviderData any
+}
+
+// RR returns the record as a [libdns.RR] with the target as data. The frame metadata is not part
+// of the data.
+func (f Frame) RR() libdns.RR {
+	return libdns.RR{
+		Name: f.Name,
+		TTL:  f.TTL,
+		Type: "FRAME",
+		Data: f.Target,
+	}
+}
+
+// isForwardingType reports whether the record type is one of the web forwarding types of INWX.
+func isForwardingType(recordType string) bool {
+	return recordType == "URL" || recordType == "FRAME"
+}
+
+// forwardingRecord returns the web forwarding record for the INWX record, if it is a URL or FRAME
+// record.
+func forwardingRecord(record nameserverRecord, name string, ttl time.Duration) (libdns.Record, bool) {
+	switch record.Type {
+	case "URL":
+		return URL{
+			Name:         name,
+			TTL:          ttl,
+			Target:       record.Content,
+			RedirectType: RedirectType(record.RedirectType),
+			AppendPath:   record.Append,
+		}, true
+	case "FRAME":
+		return Frame{
+			Name:        name,
+			TTL:         ttl,
+			Target:      record.Content,
+			Title:       record.RedirectTitle,
+			Description: record.RedirectDescription,
+			Keywords:    record.RedirectKeywords,
+			FavIcon:     record.RedirectFavIcon,
+		}, true
+	}
+
+	return nil, false
+}
+
+// getURLRedirect returns the INWX options of a web forwarding record.
+func getURLRedirect(record libdns.Record) urlRedirect {
+	switch rec := record.(type) {
+	case URL:
+		return urlRedirect{
+			RedirectType: string(rec.RedirectType),
+			Append:       rec.AppendPath,
+		}
+	case Frame:
+		return urlRedirect{
+			RedirectType:        redirectTypeFrame,
+			RedirectTitle:       rec.Title,
+			RedirectDescription: rec.Description,
+			RedirectKeywords:    rec.Keywords,
+			RedirectFavIcon:     rec.FavIcon,
+		}
+	}
+
+	if record.RR().Type == "FRAME" {
+		return urlRedirect{RedirectType: redirectTypeFrame}
+	}
+
+	return urlRedirect{}
+}
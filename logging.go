@@ -0,0 +1,75 @@
+package inwx
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// Parameters whose values are never logged, such as the password of account.login and the TAN of
+// account.unlock.
+var redactedParams = map[string]bool{
+	"pass": true,
+	"tan":  true,
+}
+
+const redacted = "[REDACTED]"
+
+// logCall logs a JSON-RPC call with its sanitized parameters. Successful calls are logged at debug
+// level and failed calls at warn level.
+func (c *client) logCall(ctx context.Context, method string, params any, code int, duration time.Duration, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	level := slog.LevelDebug
+
+	if err != nil {
+		level = slog.LevelWarn
+	}
+
+	if !c.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.Duration("duration", duration),
+		slog.Int("code", code),
+		slog.Any("params", sanitizeParams(params)),
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	c.logger.LogAttrs(ctx, level, "INWX API call", attrs...)
+}
+
+// sanitizeParams returns the parameters as they are sent to the API, with secrets redacted.
+func sanitizeParams(params any) any {
+	if params == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(params)
+
+	if err != nil {
+		return redacted
+	}
+
+	var sanitized map[string]any
+
+	if err := json.Unmarshal(data, &sanitized); err != nil {
+		return redacted
+	}
+
+	for key := range sanitized {
+		if redactedParams[key] {
+			sanitized[key] = redacted
+		}
+	}
+
+	return sanitized
+}
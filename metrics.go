@@ -0,0 +1,25 @@
+package inwx
+
+import "time"
+
+// Metrics receives measurements of the calls to the INWX API, for example to expose them as
+// Prometheus metrics. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ObserveCall is called after every attempt of a JSON-RPC call with its method, the INWX result
+	// code and the duration. The code is zero if no valid JSON-RPC response has been received.
+	ObserveCall(method string, code int, duration time.Duration)
+
+	// ObserveLogin is called after every login, including logins after the session expired.
+	ObserveLogin(err error)
+
+	// ObserveRetry is called before a failed JSON-RPC call is retried.
+	ObserveRetry(method string)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveCall(string, int, time.Duration) {}
+
+func (noopMetrics) ObserveLogin(error) {}
+
+func (noopMetrics) ObserveRetry(string) {}
@@ -3,14 +3,21 @@ package inwx
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/libdns/libdns"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Provider facilitates DNS record manipulation with INWX.
+//
+// A Provider is safe for concurrent use. Concurrent calls share a single INWX session, which is only
+// logged out once no call is using it anymore.
 type Provider struct {
 	// Username of your INWX account.
 	Username string `json:"username,omitempty"`
@@ -24,44 +31,108 @@ type Provider struct {
 	// URL of the JSON-RPC API endpoint. It defaults to the production endpoint.
 	EndpointURL string `json:"endpoint_url,omitempty"`
 
-	client   *client
-	clientMu sync.Mutex
+	// HTTP client used to send requests, for example to configure a proxy, TLS client certificates or
+	// instrumentation. Its cookie jar is replaced to track the INWX session. If it is nil, a client
+	// with a timeout of 30 seconds is used.
+	HTTPClient *http.Client `json:"-"`
+
+	// Duration for which the session is kept open after the last operation has finished, so that
+	// subsequent calls do not have to log in again. It defaults to 5 minutes. A negative value logs
+	// out as soon as no operation is in progress anymore.
+	SessionIdleTimeout time.Duration `json:"session_idle_timeout,omitempty"`
+
+	// Policy for retrying calls after transient failures, such as network errors or INWX asking to
+	// try again later.
+	RetryPolicy RetryPolicy `json:"retry_policy,omitempty"`
+
+	// Maximum number of API requests per second. The limit is shared by all providers using the same
+	// username and endpoint, because INWX enforces its limits per account. Requests are not limited
+	// if it is zero.
+	RateLimit float64 `json:"rate_limit,omitempty"`
+
+	// Number of requests which may be sent at once before the rate limit applies. It defaults to 1.
+	RateLimitBurst int `json:"rate_limit_burst,omitempty"`
+
+	// Logger for API calls. Every call is logged with its method, duration, result code and
+	// parameters, where passwords and TANs are always redacted. Successful calls are logged at debug
+	// level and failed calls at warn level. Calls are not logged if it is nil.
+	Logger *slog.Logger `json:"-"`
+
+	// Provider of the tracer for OpenTelemetry spans. Every operation of the provider and every
+	// JSON-RPC call creates a span. It defaults to the global tracer provider.
+	TracerProvider trace.TracerProvider `json:"-"`
+
+	// Receiver of metrics about API calls, logins and retries. No metrics are collected if it is nil.
+	Metrics Metrics `json:"-"`
+
+	// Whether AppendRecords, SetRecords and DeleteRecords roll back the changes they already applied
+	// if a later change fails. The error of a failed operation wraps [ErrRolledBack] if the rollback
+	// succeeded, and contains the errors of the rollback otherwise.
+	Atomic bool `json:"atomic,omitempty"`
+
+	client       *client
+	clientRefs   int
+	pendingLogin *pendingLogin
+	idleTimer    *time.Timer
+	clientMu     sync.Mutex
 }
 
+const defaultSessionIdleTimeout = 5 * time.Minute
+
+// Timeout of logging in and out, which is independent of the operations that need the session.
+const sessionCallTimeout = 30 * time.Second
+
 // GetRecords lists all the records in the zone.
-func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+func (p *Provider) GetRecords(ctx context.Context, zone string) (_ []libdns.Record, err error) {
+	ctx, span := p.startSpan(ctx, "GetRecords", zone)
+	defer func() { endSpan(span, err) }()
+
 	client, err := p.getClient(ctx)
-	defer p.removeClient(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	inwxRecords, err := client.getRecords(ctx, getDomain(zone))
+	defer p.releaseClient(ctx, client)
+
+	info, err := client.getRecords(ctx, getDomain(zone))
 
 	if err != nil {
 		return nil, err
 	}
 
-	results := make([]libdns.Record, 0, len(inwxRecords))
-
-	for _, inwxRecord := range inwxRecords {
-		result, err := libdnsRecord(inwxRecord, zone)
+	results := make([]libdns.Record, 0, len(info.Records))
 
-		if err != nil {
-			return nil, fmt.Errorf("parsing INWX DNS record %+v: %v", inwxRecord, err)
-		}
-
-		results = append(results, result)
+	for _, inwxRecord := range info.Records {
+		results = append(results, libdnsRecord(inwxRecord, zone, info.RoID))
 	}
 
 	return results, nil
 }
 
-// AppendRecords adds records to the zone. It returns the records that were added.
-func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+// AppendRecords adds records to the zone. It returns the records that were added, as they were
+// stored by INWX. The roId of the zone, which is part of the returned [ProviderData], is looked up
+// with an additional request.
+func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, span := p.startSpan(ctx, "AppendRecords", zone)
+	defer func() { endSpan(span, err) }()
+
 	client, err := p.getClient(ctx)
-	defer p.removeClient(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	tx := newTransaction(client, getDomain(zone), p.Atomic)
+	defer func() { err = tx.complete(ctx, err) }()
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	roID, err := zoneRoID(ctx, client, zone)
 
 	if err != nil {
 		return nil, err
@@ -70,111 +141,272 @@ func (p *Provider) AppendRecords(ctx context.Context, zone string, records []lib
 	var results []libdns.Record
 
 	for _, record := range records {
-		var _, err = client.createRecord(ctx, inwxRecord(record), getDomain(zone))
+		inwxRecord, err := inwxRecord(record)
 
 		if err != nil {
 			return nil, err
 		}
 
-		results = append(results, record)
+		id, err := tx.createRecord(ctx, inwxRecord)
+
+		if err != nil {
+			return nil, slaveZoneError(ctx, client, zone, err)
+		}
+
+		results = append(results, libdnsRecord(storedRecord(inwxRecord, id), zone, roID))
 	}
 
 	return results, nil
 }
 
-// SetRecords sets the records in the zone, either by updating existing records or creating new ones.
-// It returns the updated records.
-func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+// SetRecords sets the records in the zone. For every (name, type) pair in the input, the existing
+// records are replaced by the given records: matching records are kept, differing records are
+// updated in place, missing records are created and remaining records are deleted. Records
+// carrying [ProviderData] with an ID update the record with this ID. It returns the records that
+// were set, as they were stored by INWX.
+//
+// The zone is fetched once and the changes are computed locally. Records which are updated to the
+// same values and records which are deleted are modified with a single request each.
+func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, span := p.startSpan(ctx, "SetRecords", zone)
+	defer func() { endSpan(span, err) }()
+
 	client, err := p.getClient(ctx)
-	defer p.removeClient(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var results []libdns.Record
+	defer p.releaseClient(ctx, client)
 
-	for _, record := range records {
-		matches, err := p.client.findRecords(ctx, inwxRecord(record), getDomain(zone), false)
+	tx := newTransaction(client, getDomain(zone), p.Atomic)
+	defer func() { err = tx.complete(ctx, err) }()
 
-		if err != nil {
-			return nil, err
-		}
+	info, err := client.getRecords(ctx, getDomain(zone))
 
-		if len(matches) == 0 {
-			_, err := client.createRecord(ctx, inwxRecord(record), getDomain(zone))
+	if err != nil {
+		return nil, err
+	}
 
-			if err != nil {
-				return nil, err
+	err = checkMasterZone(info, zone)
+
+	if err != nil {
+		return nil, err
+	}
+
+	existingByID := map[string]nameserverRecord{}
+
+	for _, record := range info.Records {
+		existingByID[record.ID] = record
+	}
+
+	rrsets, err := groupRRsets(records, existingByID, zone)
+
+	if err != nil {
+		return nil, err
+	}
+
+	claimedBy := map[string]*rrset{}
+
+	for _, rrset := range rrsets {
+		for _, record := range rrset.records {
+			if record.ID == "" {
+				continue
 			}
 
-			results = append(results, record)
+			if _, ok := existingByID[record.ID]; !ok {
+				return nil, fmt.Errorf("record %s does not exist in zone %s: %w", record.ID, zone, ErrObjectNotFound)
+			}
 
-			continue
+			claimedBy[record.ID] = rrset
 		}
+	}
+
+	var updates updateBatches
+	var deletes []nameserverRecord
+	plans := make([]rrsetPlan, len(rrsets))
+
+	for i, rrset := range rrsets {
+		var existing []nameserverRecord
+
+		for _, record := range info.Records {
+			if owner, ok := claimedBy[record.ID]; ok && owner != rrset {
+				// The record is moved to another RRset.
+				continue
+			}
 
-		if len(matches) > 1 {
-			return nil, fmt.Errorf("unexpectedly found more than 1 record for %v", record)
+			if record.Type == rrset.recordType && isSameName(record.Name, rrset.name, getDomain(zone)) {
+				existing = append(existing, record)
+			}
 		}
 
-		inwxRecord := inwxRecord(record)
-		inwxRecord.ID = matches[0].ID
+		kept, rrsetUpdates, creates, rrsetDeletes := diffRRset(existing, rrset.records)
+
+		for _, update := range rrsetUpdates {
+			updates.add(update, existingByID[update.ID])
+		}
 
-		err = client.updateRecord(ctx, inwxRecord)
+		deletes = append(deletes, rrsetDeletes...)
+		plans[i] = rrsetPlan{stored: kept, creates: creates}
+
+		for _, update := range rrsetUpdates {
+			plans[i].stored = append(plans[i].stored, storedRecord(update, update.ID))
+		}
+	}
+
+	for _, batch := range updates {
+		err := tx.updateRecords(ctx, batch.record, batch.previous)
 
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		results = append(results, record)
+	for i := range plans {
+		for _, inwxRecord := range plans[i].creates {
+			id, err := tx.createRecord(ctx, inwxRecord)
 
+			if err != nil {
+				return nil, err
+			}
+
+			plans[i].stored = append(plans[i].stored, storedRecord(inwxRecord, id))
+		}
+	}
+
+	err = tx.deleteRecords(ctx, deletes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var results []libdns.Record
+
+	for _, plan := range plans {
+		for _, inwxRecord := range plan.stored {
+			results = append(results, libdnsRecord(inwxRecord, zone, info.RoID))
+		}
 	}
 
 	return results, nil
 }
 
-// DeleteRecords deletes the records from the zone. It returns the records that were deleted.
-func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+// DeleteRecords deletes the records from the zone. Empty type, TTL and data of a record act as
+// wildcards, which match any value of the respective field. Records carrying [ProviderData] with an
+// ID are deleted by their ID. It returns the records that were deleted, as they were stored by
+// INWX.
+//
+// The zone is fetched once and all matching records are deleted with a single request. Records
+// whose ID does not belong to the zone are rejected with an error wrapping [ErrObjectNotFound].
+func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) (_ []libdns.Record, err error) {
+	ctx, span := p.startSpan(ctx, "DeleteRecords", zone)
+	defer func() { endSpan(span, err) }()
+
 	client, err := p.getClient(ctx)
-	defer p.removeClient(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var results []libdns.Record
+	defer p.releaseClient(ctx, client)
+
+	tx := newTransaction(client, getDomain(zone), p.Atomic)
+	defer func() { err = tx.complete(ctx, err) }()
+
+	info, err := client.getRecords(ctx, getDomain(zone))
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkMasterZone(info, zone)
+
+	if err != nil {
+		return nil, err
+	}
+
+	existingByID := map[string]nameserverRecord{}
+
+	for _, record := range info.Records {
+		existingByID[record.ID] = record
+	}
+
+	filters := make([]nameserverRecord, 0, len(records))
 
 	for _, record := range records {
-		exactMatches, err := p.client.findRecords(ctx, inwxRecord(record), getDomain(zone), true)
+		filter, err := convertRecord(record, existingByID, zone)
 
 		if err != nil {
 			return nil, err
 		}
 
-		for _, inwxRecord := range exactMatches {
-			err := client.deleteRecord(ctx, inwxRecord)
+		filters = append(filters, filter)
+	}
 
-			if err != nil {
-				return nil, err
+	var matches []nameserverRecord
+	var results []libdns.Record
+	deleted := map[string]bool{}
+
+	for i, record := range records {
+		filter := filters[i]
+
+		if filter.ID != "" {
+			// The record has been returned by the provider before, so it can be deleted directly, as
+			// long as it belongs to the zone. The stored record is deleted and returned instead of the
+			// given one, whose values may be outdated.
+			stored, ok := existingByID[filter.ID]
+
+			if data, _ := providerData(record); !ok || data.RoID != 0 && data.RoID != info.RoID {
+				return nil, fmt.Errorf("record %s does not exist in zone %s: %w", filter.ID, zone, ErrObjectNotFound)
+			}
+
+			if deleted[stored.ID] {
+				continue
+			}
+
+			deleted[stored.ID] = true
+			matches = append(matches, stored)
+
+			results = append(results, libdnsRecord(stored, zone, info.RoID))
+
+			continue
+		}
+
+		for _, inwxRecord := range info.Records {
+			if deleted[inwxRecord.ID] || !isSameName(inwxRecord.Name, filter.Name, getDomain(zone)) || !matchesRecord(inwxRecord, filter) {
+				continue
 			}
 
-			results = append(results, record)
+			deleted[inwxRecord.ID] = true
+			matches = append(matches, inwxRecord)
+
+			results = append(results, libdnsRecord(inwxRecord, zone, info.RoID))
 		}
 	}
 
+	err = tx.deleteRecords(ctx, matches)
+
+	if err != nil {
+		return nil, slaveZoneError(ctx, client, zone, err)
+	}
+
 	return results, nil
 }
 
 // ListZones lists all zones (nameserver domains) available in the INWX account.
-func (p *Provider) ListZones(ctx context.Context) ([]libdns.Zone, error) {
+func (p *Provider) ListZones(ctx context.Context) (_ []libdns.Zone, err error) {
+	ctx, span := p.startSpan(ctx, "ListZones", "")
+	defer func() { endSpan(span, err) }()
+
 	client, err := p.getClient(ctx)
-	defer p.removeClient(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	domains, err := client.listNameservers(ctx)
+	defer p.releaseClient(ctx, client)
+
+	domains, err := client.listNameservers(ctx, "")
 
 	if err != nil {
 		return nil, err
@@ -191,39 +423,232 @@ func (p *Provider) ListZones(ctx context.Context) ([]libdns.Zone, error) {
 	return zones, nil
 }
 
+// Close logs out of the INWX session that is kept open between calls. Operations that are still
+// in progress lose their session, so Close should only be called once the provider is not used
+// anymore. The provider can still be used afterwards, in which case a new session is created.
+func (p *Provider) Close(ctx context.Context) error {
+	p.clientMu.Lock()
+	client := p.detachClient()
+	p.clientMu.Unlock()
+
+	if client == nil {
+		return nil
+	}
+
+	return client.logout(ctx)
+}
+
+// getClient returns the shared client and logs in if there is no open session. Every successful
+// call must be paired with a call to releaseClient.
+//
+// The login runs without holding clientMu and is shared by all calls waiting for a session. It is
+// not canceled if the calls waiting for it are, so that a session is still available for the next
+// call.
 func (p *Provider) getClient(ctx context.Context) (*client, error) {
 	p.clientMu.Lock()
-	defer p.clientMu.Unlock()
 
-	if p.client == nil {
-		client, err := newClient(p.getEndpointURL())
-		p.client = client
+	if p.idleTimer != nil {
+		p.idleTimer.Stop()
+		p.idleTimer = nil
+	}
 
-		if err != nil {
-			return nil, err
+	if p.client != nil {
+		p.clientRefs++
+		client := p.client
+		p.clientMu.Unlock()
+
+		return client, nil
+	}
+
+	login := p.pendingLogin
+
+	if login == nil {
+		login = &pendingLogin{done: make(chan struct{})}
+		p.pendingLogin = login
+
+		go p.login(ctx, login)
+	}
+
+	login.waiters++
+	p.clientMu.Unlock()
+
+	select {
+	case <-login.done:
+		// The login has counted this call as a reference of the client.
+		return login.client, login.err
+	case <-ctx.Done():
+	}
+
+	p.clientMu.Lock()
+
+	select {
+	case <-login.done:
+		// The login finished concurrently, so the reference has to be released again.
+		p.clientMu.Unlock()
+
+		if login.err == nil {
+			p.releaseClient(ctx, login.client)
 		}
+	default:
+		login.waiters--
+		p.clientMu.Unlock()
+	}
 
+	return nil, ctx.Err()
+}
+
+// pendingLogin is a login in progress, which is shared by all calls waiting for a session.
+type pendingLogin struct {
+	// Closed when the login has finished, after client and err have been set.
+	done chan struct{}
+
+	// Number of calls waiting for the login, each of which holds a reference to the client once
+	// the login has succeeded. It is guarded by clientMu.
+	waiters int
+
+	client *client
+	err    error
+}
+
+// login creates a client, logs in and installs the client as the shared client of the provider.
+func (p *Provider) login(ctx context.Context, login *pendingLogin) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sessionCallTimeout)
+	defer cancel()
+
+	var idle *client
+	client, err := newClient(p.getEndpointURL(), p.getClientOptions())
+
+	if err == nil {
 		err = client.login(ctx, p.Username, p.Password, p.SharedSecret)
+	}
 
-		if err != nil {
-			return nil, err
+	p.clientMu.Lock()
+
+	p.pendingLogin = nil
+	login.client = client
+	login.err = err
+
+	if err == nil {
+		p.client = client
+		p.clientRefs = login.waiters
+
+		if login.waiters == 0 {
+			// All calls have given up waiting, so the session is idle right away.
+			idle = p.scheduleLogout()
 		}
 	}
 
-	return p.client, nil
+	close(login.done)
+	p.clientMu.Unlock()
+
+	logout(ctx, idle)
 }
 
-func (p *Provider) removeClient(ctx context.Context) {
+// releaseClient gives back a client obtained from getClient. When the last reference is released,
+// the session is logged out after the idle timeout has passed.
+func (p *Provider) releaseClient(ctx context.Context, client *client) {
 	p.clientMu.Lock()
-	defer p.clientMu.Unlock()
 
-	if p.client == nil {
+	if p.client != client {
+		// The session has already been closed in the meantime.
+		p.clientMu.Unlock()
 		return
 	}
 
-	p.client.logout(ctx)
+	p.clientRefs--
+
+	if p.clientRefs > 0 {
+		p.clientMu.Unlock()
+		return
+	}
+
+	idle := p.scheduleLogout()
+	p.clientMu.Unlock()
+
+	logout(ctx, idle)
+}
+
+// scheduleLogout starts the idle timer of the shared client, which has no references anymore. If
+// the session is not kept open, the client is detached and returned, so that it can be logged out
+// without holding the lock. The caller must hold clientMu.
+func (p *Provider) scheduleLogout() *client {
+	timeout := p.getSessionIdleTimeout()
+
+	if timeout < 0 {
+		return p.detachClient()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		p.clientMu.Lock()
+
+		if p.idleTimer != timer {
+			// The timer has been stopped or replaced after it already fired.
+			p.clientMu.Unlock()
+			return
+		}
+
+		client := p.detachClient()
+		p.clientMu.Unlock()
+
+		logout(context.Background(), client)
+	})
+	p.idleTimer = timer
+
+	return nil
+}
+
+// detachClient removes the shared client from the provider and returns it, so that it can be
+// logged out without holding the lock. The caller must hold clientMu.
+func (p *Provider) detachClient() *client {
+	if p.idleTimer != nil {
+		p.idleTimer.Stop()
+		p.idleTimer = nil
+	}
 
+	client := p.client
 	p.client = nil
+	p.clientRefs = 0
+
+	return client
+}
+
+// logout logs out the detached client, if any. It is not canceled with the context, which may
+// belong to an operation that has already been canceled, so that the session does not stay open.
+// Failures are reported by the client's logger and metrics.
+func logout(ctx context.Context, client *client) {
+	if client == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sessionCallTimeout)
+	defer cancel()
+
+	client.logout(ctx)
+}
+
+func (p *Provider) getClientOptions() clientOptions {
+	options := clientOptions{
+		httpClient:  p.HTTPClient,
+		retryPolicy: p.RetryPolicy,
+		logger:      p.Logger,
+		tracer:      p.getTracer(),
+		metrics:     p.Metrics,
+	}
+
+	if p.RateLimit > 0 {
+		options.rateLimiter = sharedRateLimiter(p.getEndpointURL(), p.Username, p.RateLimit, p.RateLimitBurst)
+	}
+
+	return options
+}
+
+func (p *Provider) getSessionIdleTimeout() time.Duration {
+	if p.SessionIdleTimeout != 0 {
+		return p.SessionIdleTimeout
+	}
+
+	return defaultSessionIdleTimeout
 }
 
 func (p *Provider) getEndpointURL() string {
@@ -234,47 +659,251 @@ func (p *Provider) getEndpointURL() string {
 	return endpointURL
 }
 
-func getDomain(zone string) string {
-	return strings.TrimSuffix(zone, ".")
+// rrset contains the records with the same name and type.
+type rrset struct {
+	name       string
+	recordType string
+	input      []libdns.Record
+	records    []nameserverRecord
 }
 
-func libdnsRecord(record nameserverRecord, zone string) (libdns.Record, error) {
-	name := libdns.RelativeName(record.Name, getDomain(zone))
-	ttl := time.Duration(record.TTL) * time.Second
-	data := record.Content
+// groupRRsets groups the records by name and type, in the order in which they first occur. The
+// existing records of the zone are used to convert records which could not be parsed when they
+// were returned by the provider.
+func groupRRsets(records []libdns.Record, existingByID map[string]nameserverRecord, zone string) ([]*rrset, error) {
+	var rrsets []*rrset
+	index := map[[2]string]*rrset{}
+
+	for _, record := range records {
+		inwxRecord, err := convertRecord(record, existingByID, zone)
 
-	if record.Type == "MX" || record.Type == "SRV" {
-		data = fmt.Sprintf("%d %s", record.Priority, record.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		key := [2]string{inwxRecord.Name, inwxRecord.Type}
+		set, ok := index[key]
+
+		if !ok {
+			set = &rrset{name: inwxRecord.Name, recordType: inwxRecord.Type}
+			index[key] = set
+			rrsets = append(rrsets, set)
+		}
+
+		set.input = append(set.input, record)
+		set.records = append(set.records, inwxRecord)
 	}
 
-	return libdns.RR{
-		Type: record.Type,
-		Name: name,
-		Data: data,
-		TTL:  ttl,
-	}.Parse()
+	return rrsets, nil
 }
 
-func inwxRecord(record libdns.Record) nameserverRecord {
+// convertRecord converts the record into an INWX record like inwxRecord. Records whose data is
+// invalid are accepted if they have been returned by the provider with unchanged name, type and
+// data, because INWX stored them with content that cannot be parsed. They are converted into the
+// stored record with the given TTL, so that they can be passed back to the provider.
+func convertRecord(record libdns.Record, existingByID map[string]nameserverRecord, zone string) (nameserverRecord, error) {
+	converted, err := inwxRecord(record)
+
+	if err == nil {
+		return converted, nil
+	}
+
+	data, ok := providerData(record)
+
+	if !ok {
+		return nameserverRecord{}, err
+	}
+
+	stored, ok := existingByID[data.ID]
 	rr := record.RR()
 
-	inwxRecord := nameserverRecord{
-		Name:    rr.Name,
-		Type:    rr.Type,
-		Content: rr.Data,
-		TTL:     int(rr.TTL.Seconds()),
+	if !ok || libdnsRecord(stored, zone, 0).RR() != (libdns.RR{Name: rr.Name, Type: rr.Type, Data: rr.Data, TTL: time.Duration(stored.TTL) * time.Second}) {
+		return nameserverRecord{}, err
+	}
+
+	stored.Name = rr.Name
+	stored.TTL = int(rr.TTL.Seconds())
+
+	return stored, nil
+}
+
+// rrsetPlan contains the changes to an RRset which are not batched across RRsets.
+type rrsetPlan struct {
+	// Records which are stored after the change, except the records which are created.
+	stored []nameserverRecord
+
+	creates []nameserverRecord
+}
+
+// updateBatch contains records which are updated to the same values.
+type updateBatch struct {
+	record   nameserverRecord
+	previous []nameserverRecord
+}
+
+type updateBatches []*updateBatch
+
+// add adds the update of the previous record to the batch with the same values.
+func (b *updateBatches) add(update nameserverRecord, previous nameserverRecord) {
+	key := storedRecord(update, "")
+
+	for _, batch := range *b {
+		if batch.record == key {
+			batch.previous = append(batch.previous, previous)
+			return
+		}
+	}
+
+	*b = append(*b, &updateBatch{record: key, previous: []nameserverRecord{previous}})
+}
+
+// diffRRset computes the changes needed to replace the existing records of an RRset with the
+// desired records. Desired records with an ID replace the record with this ID, even if it is not
+// part of the RRset yet. Of the remaining existing records, those that already match a desired
+// record are kept and the others are reused for updates before new records are created or
+// leftovers are deleted.
+func diffRRset(existing []nameserverRecord, desired []nameserverRecord) (kept []nameserverRecord, updates []nameserverRecord, creates []nameserverRecord, deletes []nameserverRecord) {
+	unmatched := append([]nameserverRecord(nil), existing...)
+	var withoutID []nameserverRecord
+
+	for _, record := range desired {
+		if record.ID == "" {
+			withoutID = append(withoutID, record)
+			continue
+		}
+
+		index := slices.IndexFunc(unmatched, func(existing nameserverRecord) bool {
+			return existing.ID == record.ID
+		})
+
+		if index >= 0 && hasSameValue(unmatched[index], record) {
+			kept = append(kept, unmatched[index])
+		} else {
+			updates = append(updates, record)
+		}
+
+		if index >= 0 {
+			unmatched = slices.Delete(unmatched, index, index+1)
+		}
+	}
+
+	for _, record := range withoutID {
+		index := slices.IndexFunc(unmatched, func(existing nameserverRecord) bool {
+			return hasSameValue(existing, record)
+		})
+
+		if index >= 0 {
+			kept = append(kept, unmatched[index])
+			unmatched = slices.Delete(unmatched, index, index+1)
+			continue
+		}
+
+		creates = append(creates, record)
+	}
+
+	for len(creates) > 0 && len(unmatched) > 0 {
+		update := creates[0]
+		update.ID = unmatched[0].ID
+
+		updates = append(updates, update)
+		creates = creates[1:]
+		unmatched = unmatched[1:]
+	}
+
+	return kept, updates, creates, unmatched
+}
+
+// hasSameValue reports whether the existing record already has the data, TTL and web forwarding
+// options the record would be stored with.
+func hasSameValue(existing nameserverRecord, record nameserverRecord) bool {
+	return hasSameData(existing, record) &&
+		existing.TTL == ensureMinTTL(record.TTL) &&
+		hasSameRedirect(existing, record)
+}
+
+// hasSameData reports whether the existing record has the same data as the record. Both are
+// normalized by converting them to the data of libdns and back, because INWX may store the same
+// data in different ways, e.g. legacy HTTPS records with the priority as part of the content.
+func hasSameData(existing nameserverRecord, record nameserverRecord) bool {
+	if existing.Content == record.Content && existing.Priority == record.Priority {
+		return true
+	}
+
+	content, priority, ok := normalizeData(existing)
+	recordContent, recordPriority, recordOK := normalizeData(record)
+
+	return ok && recordOK && content == recordContent && priority == recordPriority
+}
+
+// normalizeData returns the content and priority with which the record would be stored if it was
+// created from its libdns data.
+func normalizeData(record nameserverRecord) (string, uint, bool) {
+	conversion := getRecordConversion(record.Type)
+	data, err := conversion.toData(record.Content, record.Priority)
+
+	if err != nil {
+		return "", 0, false
 	}
 
-	switch rec := record.(type) {
-	case libdns.MX:
-		inwxRecord.Content = rec.Target
-		inwxRecord.Priority = uint(rec.Preference)
-	case libdns.SRV:
-		inwxRecord.Content = fmt.Sprintf("%d %d %s", rec.Weight, rec.Port, rec.Target)
-		inwxRecord.Priority = uint(rec.Priority)
+	content, priority, err := conversion.fromData(data)
+
+	return content, priority, err == nil
+}
+
+// hasSameRedirect reports whether the web forwarding options of the existing record equal those of
+// the record. All options are compared, so that options which are not set anymore are cleared, but
+// an empty redirect type keeps the current type.
+func hasSameRedirect(existing nameserverRecord, record nameserverRecord) bool {
+	if !isForwardingType(record.Type) {
+		return true
+	}
+
+	redirect := record.urlRedirect
+
+	if redirect.RedirectType == "" {
+		redirect.RedirectType = existing.RedirectType
 	}
 
-	return inwxRecord
+	return existing.urlRedirect == redirect
+}
+
+// matchesRecord reports whether the record matches the filter, which has been converted from a
+// libdns record. An empty type, TTL or content of the filter matches any value.
+func matchesRecord(record nameserverRecord, filter nameserverRecord) bool {
+	if filter.Type != "" && record.Type != filter.Type {
+		return false
+	}
+
+	if filter.TTL != 0 && record.TTL != ensureMinTTL(filter.TTL) {
+		return false
+	}
+
+	if filter.Content != "" || filter.Priority != 0 {
+		return hasSameData(record, filter)
+	}
+
+	return true
+}
+
+// isSameName reports whether the fully qualified name returned by INWX and the libdns name, which
+// is usually relative to the zone, refer to the same domain name.
+func isSameName(inwxName string, name string, domain string) bool {
+	return strings.EqualFold(
+		libdns.RelativeName(inwxName, domain),
+		libdns.RelativeName(libdns.AbsoluteName(name, domain), domain),
+	)
+}
+
+func getDomain(zone string) string {
+	return strings.TrimSuffix(zone, ".")
+}
+
+// storedRecord returns the record as it is stored by INWX after it has been sent with the given ID.
+func storedRecord(record nameserverRecord, id string) nameserverRecord {
+	record.ID = id
+	record.TTL = ensureMinTTL(record.TTL)
+
+	return record
 }
 
 // Interface guards
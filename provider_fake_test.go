@@ -0,0 +1,2293 @@
+package inwx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const fakeSessionCookie = "domrobot"
+
+// fakeServer is an in-memory implementation of the parts of the INWX JSON-RPC API used by the
+// provider. It is used to test the provider without access to the OTE environment.
+type fakeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	sessions map[string]bool
+	zones    map[string][]nameserverRecord
+	roIDs    map[string]int
+	creates  map[string]nameserverCreateRequest
+	dnssec   map[string]string
+	keys     map[string][]dnssecKey
+	nextID   int
+	calls    map[string]int
+	failures map[string][]fakeFailure
+
+	// Logins wait until the channel is closed, if it is set.
+	loginGate chan struct{}
+}
+
+// fakeFailure is returned instead of the regular response to simulate a failing call. The zero
+// value lets the call pass.
+type fakeFailure struct {
+	status int
+	code   int
+	body   string
+
+	// Whether the call is executed before the failure is returned, as if the response was lost.
+	executed bool
+}
+
+type fakeRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+func newFakeServer(t *testing.T, zones ...string) *fakeServer {
+	s := &fakeServer{
+		sessions: map[string]bool{},
+		zones:    map[string][]nameserverRecord{},
+		roIDs:    map[string]int{},
+		creates:  map[string]nameserverCreateRequest{},
+		dnssec:   map[string]string{},
+		keys:     map[string][]dnssecKey{},
+		nextID:   1,
+		calls:    map[string]int{},
+		failures: map[string][]fakeFailure{},
+	}
+
+	for i, zone := range zones {
+		s.zones[getDomain(zone)] = []nameserverRecord{}
+		s.roIDs[getDomain(zone)] = 1000 + i
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *fakeServer) provider() *Provider {
+	return &Provider{
+		Username:    "user",
+		Password:    "pass",
+		EndpointURL: s.URL,
+	}
+}
+
+func (s *fakeServer) callCount(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[method]
+}
+
+// fail makes the next calls of the method fail with the given failures, one per call.
+func (s *fakeServer) fail(method string, failures ...fakeFailure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[method] = append(s.failures[method], failures...)
+}
+
+// expireSessions invalidates all sessions, as INWX does when a session times out.
+func (s *fakeServer) expireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions = map[string]bool{}
+}
+
+// seed adds records to the zone. The names of the records are relative to the zone.
+func (s *fakeServer) seed(zone string, records ...nameserverRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, record := range records {
+		record.ID = strconv.Itoa(s.nextID)
+		record.Name = fakeAbsoluteName(record.Name, getDomain(zone))
+		s.nextID++
+		s.zones[getDomain(zone)] = append(s.zones[getDomain(zone)], record)
+	}
+}
+
+func (s *fakeServer) records(zone string) []nameserverRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]nameserverRecord(nil), s.zones[getDomain(zone)]...)
+}
+
+func (s *fakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	var request fakeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if request.Method == "account.login" && s.loginGate != nil {
+		<-s.loginGate
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls[request.Method]++
+
+	session := ""
+	if cookie, err := r.Cookie(fakeSessionCookie); err == nil {
+		session = cookie.Value
+	}
+
+	if request.Method != "account.login" && !s.sessions[session] {
+		s.write(w, response{Code: 2200, Message: "Authentication error"})
+		return
+	}
+
+	if failures := s.failures[request.Method]; len(failures) > 0 && failures[0] != (fakeFailure{}) {
+		s.failures[request.Method] = failures[1:]
+
+		if failures[0].executed {
+			s.nameserver(request)
+		}
+
+		if failures[0].status != 0 {
+			w.Header().Set("content-type", "text/html")
+			w.WriteHeader(failures[0].status)
+			w.Write([]byte(failures[0].body))
+			return
+		}
+
+		s.write(w, response{Code: failures[0].code, Message: "Command failed"})
+		return
+	} else if len(failures) > 0 {
+		s.failures[request.Method] = failures[1:]
+	}
+
+	var resp response
+
+	switch request.Method {
+	case "account.login":
+		session = strconv.Itoa(s.calls["account.login"])
+		s.sessions[session] = true
+		http.SetCookie(w, &http.Cookie{Name: fakeSessionCookie, Value: session})
+		resp = response{Code: 1000, ResponseData: accountLoginResponse{}}
+	case "account.logout":
+		delete(s.sessions, session)
+		resp = response{Code: 1500}
+	default:
+		resp = s.nameserver(request)
+	}
+
+	s.write(w, resp)
+}
+
+func (s *fakeServer) nameserver(request fakeRequest) response {
+	switch request.Method {
+	case "nameserver.info":
+		var params nameserverInfoRequest
+		json.Unmarshal(request.Params, &params)
+
+		records, ok := s.zones[params.Domain]
+
+		if !ok {
+			return response{Code: 2303, Message: "Object does not exist"}
+		}
+
+		var matches []nameserverRecord
+
+		for _, record := range records {
+			if params.Name != "" && record.Name != fakeAbsoluteName(params.Name, params.Domain) ||
+				params.Type != "" && record.Type != params.Type ||
+				params.Content != "" && record.Content != params.Content {
+				continue
+			}
+
+			matches = append(matches, record)
+		}
+
+		return response{Code: 1000, ResponseData: nameserverInfoResponse{
+			RoID:    s.roIDs[params.Domain],
+			Domain:  params.Domain,
+			Type:    s.zoneType(params.Domain),
+			Count:   len(matches),
+			Records: matches,
+		}}
+	case "nameserver.createRecord":
+		var params nameserverCreateRecordRequest
+		json.Unmarshal(request.Params, &params)
+
+		if _, ok := s.zones[params.Domain]; !ok {
+			return response{Code: 2303, Message: "Object does not exist"}
+		}
+
+		if s.zoneType(params.Domain) == "SLAVE" {
+			return response{Code: 2304, Message: "Object status prohibits operation"}
+		}
+
+		record := nameserverRecord{
+			ID:       strconv.Itoa(s.nextID),
+			Name:     fakeAbsoluteName(params.Name, params.Domain),
+			Type:     params.Type,
+			Content:  params.Content,
+			TTL:      params.TTL,
+			Priority: params.Priority,
+
+			urlRedirect: params.urlRedirect,
+		}
+		s.nextID++
+		s.zones[params.Domain] = append(s.zones[params.Domain], record)
+
+		return response{Code: 1000, ResponseData: nameserverCreateRecordResponse{ID: record.ID}}
+	case "nameserver.updateRecord":
+		var params struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.Unmarshal(request.Params, &params)
+
+		// Only the fields which have been sent are updated, as INWX does.
+		var fields map[string]json.RawMessage
+		json.Unmarshal(request.Params, &fields)
+		delete(fields, "id")
+		update, _ := json.Marshal(fields)
+
+		for _, id := range fakeIDs(params.ID) {
+			domain, i, ok := s.findRecord(id)
+
+			if !ok {
+				return response{Code: 2303, Message: "Object does not exist"}
+			}
+
+			record := s.zones[domain][i]
+			json.Unmarshal(update, &record)
+
+			if _, ok := fields["name"]; ok {
+				record.Name = fakeAbsoluteName(record.Name, domain)
+			}
+
+			s.zones[domain][i] = record
+		}
+
+		return response{Code: 1000}
+	case "nameserver.deleteRecord":
+		var params struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.Unmarshal(request.Params, &params)
+
+		for _, id := range fakeIDs(params.ID) {
+			if domain, _, ok := s.findRecord(id); !ok {
+				return response{Code: 2303, Message: "Object does not exist"}
+			} else if s.zoneType(domain) == "SLAVE" {
+				return response{Code: 2304, Message: "Object status prohibits operation"}
+			}
+		}
+
+		for _, id := range fakeIDs(params.ID) {
+			domain, i, _ := s.findRecord(id)
+			s.zones[domain] = slices.Delete(s.zones[domain], i, i+1)
+		}
+
+		return response{Code: 1000}
+	case "nameserver.create":
+		var params nameserverCreateRequest
+		json.Unmarshal(request.Params, &params)
+
+		if _, ok := s.zones[params.Domain]; ok {
+			return response{Code: 2302, Message: "Object exists"}
+		}
+
+		s.zones[params.Domain] = []nameserverRecord{}
+		s.roIDs[params.Domain] = 1000 + len(s.roIDs)
+		s.creates[params.Domain] = params
+
+		return response{Code: 1000, ResponseData: map[string]int{"roId": s.roIDs[params.Domain]}}
+	case "nameserver.delete":
+		var params nameserverDeleteRequest
+		json.Unmarshal(request.Params, &params)
+
+		if _, ok := s.zones[params.Domain]; !ok {
+			return response{Code: 2303, Message: "Object does not exist"}
+		}
+
+		delete(s.zones, params.Domain)
+		delete(s.roIDs, params.Domain)
+
+		return response{Code: 1000}
+	case "nameserver.update":
+		var params nameserverUpdateRequest
+		json.Unmarshal(request.Params, &params)
+
+		create, ok := s.creates[params.Domain]
+
+		if !ok {
+			return response{Code: 2303, Message: "Object does not exist"}
+		}
+
+		create.MasterIP = params.MasterIP
+		s.creates[params.Domain] = create
+
+		return response{Code: 1000}
+	case "nameserver.transfer":
+		var params nameserverTransferRequest
+		json.Unmarshal(request.Params, &params)
+
+		if _, ok := s.zones[params.Domain]; !ok {
+			return response{Code: 2303, Message: "Object does not exist"}
+		}
+
+		if s.zoneType(params.Domain) != "SLAVE" {
+			return response{Code: 2304, Message: "Object status prohibits operation"}
+		}
+
+		return response{Code: 1000}
+	case "nameserver.list":
+		var params nameserverListRequest
+		json.Unmarshal(request.Params, &params)
+
+		var domains []nameserverListItem
+
+		for domain := range s.zones {
+			if matched, _ := path.Match(params.Domain, domain); params.Domain != "" && !matched {
+				continue
+			}
+
+			domains = append(domains, nameserverListItem{
+				RoID:     s.roIDs[domain],
+				Domain:   domain,
+				Type:     s.zoneType(domain),
+				MasterIP: s.creates[domain].MasterIP,
+			})
+		}
+
+		return response{Code: 1000, ResponseData: nameserverListResponse{Count: len(domains), Domains: domains}}
+	case "dnssec.info":
+		var params dnssecInfoRequest
+		json.Unmarshal(request.Params, &params)
+
+		var items []dnssecInfoItem
+
+		for _, domain := range params.Domains {
+			if status, ok := s.dnssec[domain]; ok {
+				items = append(items, dnssecInfoItem{Domain: domain, KeyCount: 1, Status: status})
+			}
+		}
+
+		return response{Code: 1000, ResponseData: dnssecInfoResponse{Data: items}}
+	case "dnssec.enableDNSSEC", "dnssec.disableDNSSEC", "dnssec.keyRollover", "dnssec.listKeys":
+		var params dnssecDomainRequest
+		json.Unmarshal(request.Params, &params)
+
+		return s.dnssecCall(request.Method, params.DomainName)
+	}
+
+	return response{Code: 2000, Message: "Unknown command"}
+}
+
+func (s *fakeServer) dnssecCall(method string, domain string) response {
+	if _, ok := s.zones[domain]; !ok {
+		return response{Code: 2303, Message: "Object does not exist"}
+	}
+
+	_, enabled := s.dnssec[domain]
+
+	switch {
+	case method == "dnssec.enableDNSSEC" && enabled:
+		return response{Code: 2302, Message: "Object exists"}
+	case method != "dnssec.enableDNSSEC" && method != "dnssec.listKeys" && !enabled:
+		return response{Code: 2304, Message: "Object status prohibits operation"}
+	}
+
+	switch method {
+	case "dnssec.enableDNSSEC", "dnssec.keyRollover":
+		s.dnssec[domain] = "AUTO"
+		s.keys[domain] = []dnssecKey{
+			{ID: strconv.Itoa(s.nextID), OwnerName: domain, KeyTag: uint16(s.nextID), FlagID: 257, AlgorithmID: 13, PublicKey: "KSK", DigestTypeID: 2, Digest: "ABCDEF", Status: "PUBLISHED", Active: true},
+			{ID: strconv.Itoa(s.nextID + 1), OwnerName: domain, KeyTag: uint16(s.nextID + 1), FlagID: 256, AlgorithmID: 13, PublicKey: "ZSK", Status: "PUBLISHED", Active: true},
+		}
+		s.nextID += 2
+	case "dnssec.disableDNSSEC":
+		delete(s.dnssec, domain)
+		delete(s.keys, domain)
+	case "dnssec.listKeys":
+		return response{Code: 1000, ResponseData: dnssecListKeysResponse{Keys: s.keys[domain]}}
+	}
+
+	return response{Code: 1000}
+}
+
+// zoneType returns the type with which the zone has been created, or MASTER for zones the server
+// has been started with.
+func (s *fakeServer) zoneType(domain string) string {
+	if create, ok := s.creates[domain]; ok {
+		return create.Type
+	}
+
+	return "MASTER"
+}
+
+func (s *fakeServer) findRecord(id string) (string, int, bool) {
+	for domain, records := range s.zones {
+		for i, record := range records {
+			if record.ID == id {
+				return domain, i, true
+			}
+		}
+	}
+
+	return "", 0, false
+}
+
+// fakeIDs parses a record ID parameter, which is either a single ID or an array of IDs.
+func fakeIDs(raw json.RawMessage) []string {
+	var ids []string
+
+	if err := json.Unmarshal(raw, &ids); err == nil {
+		return ids
+	}
+
+	var id string
+	json.Unmarshal(raw, &id)
+
+	return []string{id}
+}
+
+func (s *fakeServer) write(w http.ResponseWriter, resp response) {
+	w.Header().Set("content-type", "application/json; charset=UTF-8")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func fakeAbsoluteName(name string, domain string) string {
+	if name == "" || name == "@" {
+		return domain
+	}
+
+	return strings.TrimSuffix(name, ".") + "." + domain
+}
+
+func TestProvider_SessionIsShared(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	for i := 0; i < 3; i++ {
+		if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if logins := server.callCount("account.login"); logins != 1 {
+		t.Fatalf("expected 1 login, got %d", logins)
+	}
+
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if logouts := server.callCount("account.logout"); logouts != 1 {
+		t.Fatalf("expected 1 logout, got %d", logouts)
+	}
+
+	if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	if logins := server.callCount("account.login"); logins != 2 {
+		t.Fatalf("expected a new login after Close, got %d logins", logins)
+	}
+}
+
+func TestProvider_SessionIdleTimeout(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+	p.SessionIdleTimeout = 10 * time.Millisecond
+
+	if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+
+	for server.callCount("account.logout") == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("session was not logged out after the idle timeout")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestProvider_LoginDoesNotBlockOtherCalls(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	server.loginGate = make(chan struct{})
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	result := make(chan error, 1)
+
+	go func() {
+		_, err := p.GetRecords(context.Background(), "example.com.")
+		result <- err
+	}()
+
+	// A call which gives up waiting for the login does not cancel it.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := p.GetRecords(ctx, "example.com."); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the call to time out while waiting for the login, got %v", err)
+	}
+
+	closed := make(chan error, 1)
+
+	go func() {
+		closed <- p.Close(context.Background())
+	}()
+
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("unexpected error closing the provider: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close was blocked by the login in progress")
+	}
+
+	close(server.loginGate)
+
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+
+	if logins := server.callCount("account.login"); logins != 1 {
+		t.Errorf("expected the calls to share a single login, got %d logins", logins)
+	}
+}
+
+func TestProvider_ImmediateLogoutIgnoresCanceledContext(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+	p.SessionIdleTimeout = -1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	p.releaseClient(ctx, client)
+
+	if logouts := server.callCount("account.logout"); logouts != 1 {
+		t.Errorf("expected the session to be logged out although the context was canceled, got %d logouts", logouts)
+	}
+}
+
+func TestProvider_ConcurrentAppendAndDelete(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	const workers = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			records := []libdns.Record{
+				libdns.TXT{
+					Name: fmt.Sprintf("_acme-challenge.%d", i),
+					Text: fmt.Sprintf("token_%d", i),
+					TTL:  300 * time.Second,
+				},
+			}
+
+			if _, err := p.AppendRecords(context.Background(), "example.com.", records); err != nil {
+				errs <- err
+				return
+			}
+
+			deleted, err := p.DeleteRecords(context.Background(), "example.com.", records)
+
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			if len(deleted) != 1 {
+				errs <- fmt.Errorf("expected 1 deleted record for worker %d, got %d", i, len(deleted))
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if records := server.records("example.com."); len(records) != 0 {
+		t.Fatalf("expected empty zone, got %v", records)
+	}
+
+	if logins := server.callCount("account.login"); logins != 1 {
+		t.Fatalf("expected concurrent calls to share 1 login, got %d", logins)
+	}
+
+	if logouts := server.callCount("account.logout"); logouts != 0 {
+		t.Fatalf("expected session to stay open while idle, got %d logouts", logouts)
+	}
+}
+
+func TestProvider_ConcurrentCallsWithImmediateLogout(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+	p.SessionIdleTimeout = -1
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if logins, logouts := server.callCount("account.login"), server.callCount("account.logout"); logins != logouts {
+		t.Fatalf("expected every login to be logged out, got %d logins and %d logouts", logins, logouts)
+	}
+}
+
+func TestProvider_ReauthenticatesExpiredSession(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	server.expireSessions()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if logins := server.callCount("account.login"); logins != 2 {
+		t.Fatalf("expected exactly 1 additional login after the session expired, got %d logins", logins)
+	}
+}
+
+func TestProvider_ErrorsMatchResultCodes(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	_, err := p.GetRecords(context.Background(), "unknown.com.")
+
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+
+	if errors.Is(err, ErrObjectExists) {
+		t.Fatalf("expected error not to match ErrObjectExists: %v", err)
+	}
+
+	var apiError *APIError
+
+	if !errors.As(err, &apiError) || apiError.Code != 2303 {
+		t.Fatalf("expected APIError with code 2303, got %v", err)
+	}
+}
+
+func TestProvider_RetriesTransientFailures(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+	p.RetryPolicy = RetryPolicy{BaseDelay: time.Millisecond}
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.fail("nameserver.info", fakeFailure{code: 2400}, fakeFailure{status: http.StatusBadGateway})
+
+	if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := server.callCount("nameserver.info"); calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+
+	server.fail("nameserver.info", fakeFailure{code: 2400}, fakeFailure{code: 2400}, fakeFailure{code: 2400})
+
+	_, err := p.GetRecords(context.Background(), "example.com.")
+
+	var apiError *APIError
+
+	if !errors.As(err, &apiError) || apiError.Code != 2400 {
+		t.Fatalf("expected error with code 2400 after all attempts failed, got %v", err)
+	}
+}
+
+func TestProvider_DoesNotRetryAmbiguousCreate(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+	p.RetryPolicy = RetryPolicy{BaseDelay: time.Millisecond}
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	records := []libdns.Record{
+		libdns.TXT{Name: "test", Text: "value", TTL: 300 * time.Second},
+	}
+
+	server.fail("nameserver.createRecord", fakeFailure{status: http.StatusBadGateway})
+
+	if _, err := p.AppendRecords(context.Background(), "example.com.", records); err == nil {
+		t.Fatal("expected error for failed create")
+	}
+
+	if calls := server.callCount("nameserver.createRecord"); calls != 1 {
+		t.Fatalf("expected create not to be retried after a server error, got %d attempts", calls)
+	}
+
+	server.fail("nameserver.createRecord", fakeFailure{code: 2400})
+
+	if _, err := p.AppendRecords(context.Background(), "example.com.", records); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := server.callCount("nameserver.createRecord"); calls != 3 {
+		t.Fatalf("expected create to be retried after INWX reported a failure, got %d attempts", calls)
+	}
+}
+
+func TestProvider_RetriedDeleteSucceedsIfExecuted(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+	p.RetryPolicy = RetryPolicy{BaseDelay: time.Millisecond}
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	ctx := context.Background()
+	records := []libdns.Record{
+		libdns.TXT{Name: "a", Text: "value", TTL: 300 * time.Second},
+		libdns.TXT{Name: "b", Text: "value", TTL: 300 * time.Second},
+	}
+
+	if _, err := p.AppendRecords(ctx, "example.com.", records); err != nil {
+		t.Fatal(err)
+	}
+
+	server.fail("nameserver.deleteRecord", fakeFailure{status: http.StatusBadGateway, executed: true})
+
+	deleted, err := p.DeleteRecords(ctx, "example.com.", records)
+
+	if err != nil {
+		t.Fatalf("expected retried delete to succeed after the first attempt has been executed, got %v", err)
+	}
+
+	if len(deleted) != 2 {
+		t.Fatalf("expected 2 deleted records, got %d", len(deleted))
+	}
+
+	if err := p.EnableDNSSEC(ctx, "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without an ambiguous failure before, the result of INWX is returned unchanged.
+	if err := p.EnableDNSSEC(ctx, "example.com."); err == nil {
+		t.Fatal("expected error for enabling DNSSEC twice")
+	}
+
+	server.fail("dnssec.enableDNSSEC", fakeFailure{status: http.StatusBadGateway, executed: true})
+
+	if err := p.DisableDNSSEC(ctx, "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.EnableDNSSEC(ctx, "example.com."); err != nil {
+		t.Fatalf("expected retried enable to succeed after the first attempt has been executed, got %v", err)
+	}
+}
+
+type countingTransport struct {
+	mu       sync.Mutex
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requests++
+	t.mu.Unlock()
+
+	return http.DefaultTransport.RoundTrip(request)
+}
+
+func TestProvider_UsesCustomHTTPClient(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	transport := &countingTransport{}
+	httpClient := &http.Client{Transport: transport}
+
+	p := server.provider()
+	p.HTTPClient = httpClient
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	if transport.requests != 2 {
+		t.Fatalf("expected login and info requests to use the custom transport, got %d requests", transport.requests)
+	}
+
+	if httpClient.Jar != nil {
+		t.Fatal("expected the custom HTTP client not to be modified")
+	}
+}
+
+func TestProvider_ReportsUnexpectedHTTPResponses(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+	p.RetryPolicy = RetryPolicy{MaxAttempts: 1}
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	body := "<html>" + strings.Repeat("maintenance ", 100) + "</html>"
+	server.fail("nameserver.info", fakeFailure{status: http.StatusServiceUnavailable, body: body})
+
+	_, err := p.GetRecords(context.Background(), "example.com.")
+
+	var httpError *HTTPError
+
+	if !errors.As(err, &httpError) {
+		t.Fatalf("expected HTTPError, got %v", err)
+	}
+
+	if httpError.StatusCode != http.StatusServiceUnavailable || httpError.ContentType != "text/html" {
+		t.Fatalf("unexpected status or content type in %+v", httpError)
+	}
+
+	if len(httpError.Body) != httpErrorBodySize || !strings.HasPrefix(body, httpError.Body) {
+		t.Fatalf("expected body to be truncated to %d bytes, got %q", httpErrorBodySize, httpError.Body)
+	}
+
+	server.fail("nameserver.info", fakeFailure{status: http.StatusOK, body: "<html>maintenance</html>"})
+
+	_, err = p.GetRecords(context.Background(), "example.com.")
+
+	if !errors.As(err, &httpError) || httpError.StatusCode != http.StatusOK || httpError.Body != "<html>maintenance</html>" {
+		t.Fatalf("expected HTTPError for HTML response, got %v", err)
+	}
+}
+
+func TestProvider_LogsCallsWithRedactedSecrets(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	var output bytes.Buffer
+
+	p := server.provider()
+	p.Password = "s3cret-password"
+	p.Logger = slog.New(slog.NewJSONHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := output.String()
+
+	if strings.Contains(logs, p.Password) {
+		t.Fatalf("expected password to be redacted, got %s", logs)
+	}
+
+	for _, expected := range []string{`"method":"account.login"`, `"pass":"[REDACTED]"`, `"method":"nameserver.info"`, `"domain":"example.com"`, `"code":1000`} {
+		if !strings.Contains(logs, expected) {
+			t.Fatalf("expected logs to contain %s, got %s", expected, logs)
+		}
+	}
+}
+
+func TestProvider_TracesOperationsAndCalls(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	exporter := tracetest.NewInMemoryExporter()
+
+	p := server.provider()
+	p.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	operation, ok := find(spans, func(span tracetest.SpanStub) bool {
+		return span.Name == "inwx.GetRecords"
+	})
+
+	if !ok {
+		t.Fatalf("expected span for GetRecords, got %v", spans)
+	}
+
+	for _, method := range []string{"account.login", "nameserver.info"} {
+		call, ok := find(spans, func(span tracetest.SpanStub) bool {
+			return span.Name == method
+		})
+
+		if !ok {
+			t.Fatalf("expected span for %s, got %v", method, spans)
+		}
+
+		if call.Parent.SpanID() != operation.SpanContext.SpanID() {
+			t.Fatalf("expected span for %s to be a child of the GetRecords span", method)
+		}
+
+		for _, expected := range []attribute.KeyValue{
+			attribute.String("rpc.method", method),
+			attribute.String("inwx.zone", "example.com."),
+			attribute.Int("inwx.code", 1000),
+		} {
+			if !contains(call.Attributes, func(actual attribute.KeyValue) bool { return actual == expected }) {
+				t.Fatalf("expected span for %s to have attribute %v, got %v", method, expected, call.Attributes)
+			}
+		}
+	}
+}
+
+type recordingMetrics struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	logins  int
+	retries map[string]int
+}
+
+func (m *recordingMetrics) ObserveCall(method string, code int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls[fmt.Sprintf("%s %d", method, code)]++
+}
+
+func (m *recordingMetrics) ObserveLogin(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err == nil {
+		m.logins++
+	}
+}
+
+func (m *recordingMetrics) ObserveRetry(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.retries[method]++
+}
+
+func TestProvider_CollectsMetrics(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	metrics := &recordingMetrics{calls: map[string]int{}, retries: map[string]int{}}
+
+	p := server.provider()
+	p.Metrics = metrics
+	p.RetryPolicy = RetryPolicy{BaseDelay: time.Millisecond}
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.fail("nameserver.info", fakeFailure{code: 2400})
+
+	if _, err := p.GetRecords(context.Background(), "example.com."); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCalls := map[string]int{
+		"account.login 1000":   1,
+		"nameserver.info 2400": 1,
+		"nameserver.info 1000": 1,
+	}
+
+	for call, count := range expectedCalls {
+		if metrics.calls[call] != count {
+			t.Fatalf("expected %d observed calls %q, got %v", count, call, metrics.calls)
+		}
+	}
+
+	if metrics.logins != 1 {
+		t.Fatalf("expected 1 observed login, got %d", metrics.logins)
+	}
+
+	if metrics.retries["nameserver.info"] != 1 {
+		t.Fatalf("expected 1 observed retry, got %v", metrics.retries)
+	}
+}
+
+func TestProvider_SetRecordsReplacesRRsets(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.seed("example.com.",
+		nameserverRecord{Name: "multi", Type: "TXT", Content: "value_1", TTL: 300},
+		nameserverRecord{Name: "multi", Type: "TXT", Content: "value_2", TTL: 300},
+		nameserverRecord{Name: "multi", Type: "TXT", Content: "value_3", TTL: 300},
+		nameserverRecord{Name: "multi", Type: "A", Content: "192.0.2.1", TTL: 300},
+		nameserverRecord{Name: "other", Type: "TXT", Content: "other", TTL: 300},
+	)
+
+	input := []libdns.Record{
+		libdns.TXT{Name: "multi", Text: "value_1", TTL: 300 * time.Second},
+		libdns.TXT{Name: "multi", Text: "value_4", TTL: 300 * time.Second},
+		libdns.TXT{Name: "new", Text: "new_1", TTL: 300 * time.Second},
+		libdns.TXT{Name: "new", Text: "new_2", TTL: 300 * time.Second},
+	}
+
+	results, err := p.SetRecords(context.Background(), "example.com.", input)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != len(input) {
+		t.Fatalf("expected %d records to be returned, got %v", len(input), results)
+	}
+
+	records, err := p.GetRecords(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := append(input,
+		libdns.Address{Name: "multi", IP: netip.MustParseAddr("192.0.2.1"), TTL: 300 * time.Second},
+		libdns.TXT{Name: "other", Text: "other", TTL: 300 * time.Second},
+	)
+
+	if len(records) != len(expected) {
+		t.Fatalf("expected %d records in zone, got %v", len(expected), records)
+	}
+
+	for _, record := range expected {
+		if !contains(records, func(actual libdns.Record) bool { return compareRecords(actual, record) }) {
+			t.Fatalf("expected zone to contain %v, got %v", record, records)
+		}
+	}
+
+	if calls := server.callCount("nameserver.updateRecord"); calls != 1 {
+		t.Fatalf("expected 1 record to be updated in place, got %d updates", calls)
+	}
+
+	if calls := server.callCount("nameserver.deleteRecord"); calls != 1 {
+		t.Fatalf("expected 1 leftover record to be deleted, got %d deletes", calls)
+	}
+}
+
+func TestProvider_DeleteRecordsWithWildcards(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.seed("example.com.",
+		nameserverRecord{Name: "name", Type: "TXT", Content: "value_1", TTL: 300},
+		nameserverRecord{Name: "name", Type: "TXT", Content: "value_2", TTL: 3600},
+		nameserverRecord{Name: "name", Type: "A", Content: "192.0.2.1", TTL: 300},
+		nameserverRecord{Name: "type", Type: "TXT", Content: "value_1", TTL: 300},
+		nameserverRecord{Name: "type", Type: "TXT", Content: "value_2", TTL: 300},
+		nameserverRecord{Name: "type", Type: "A", Content: "192.0.2.1", TTL: 300},
+		nameserverRecord{Name: "ttl", Type: "TXT", Content: "value_1", TTL: 300},
+		nameserverRecord{Name: "ttl", Type: "TXT", Content: "value_2", TTL: 3600},
+		nameserverRecord{Name: "exact", Type: "TXT", Content: "value_1", TTL: 300},
+		nameserverRecord{Name: "exact", Type: "TXT", Content: "value_1", TTL: 3600},
+	)
+
+	tests := []struct {
+		name     string
+		input    libdns.Record
+		expected []libdns.Record
+	}{
+		{
+			name:  "name only",
+			input: libdns.RR{Name: "name"},
+			expected: []libdns.Record{
+				libdns.TXT{Name: "name", Text: "value_1", TTL: 300 * time.Second},
+				libdns.TXT{Name: "name", Text: "value_2", TTL: 3600 * time.Second},
+				libdns.Address{Name: "name", IP: netip.MustParseAddr("192.0.2.1"), TTL: 300 * time.Second},
+			},
+		},
+		{
+			name:  "name and type",
+			input: libdns.RR{Name: "type", Type: "TXT"},
+			expected: []libdns.Record{
+				libdns.TXT{Name: "type", Text: "value_1", TTL: 300 * time.Second},
+				libdns.TXT{Name: "type", Text: "value_2", TTL: 300 * time.Second},
+			},
+		},
+		{
+			name:  "name, type and TTL",
+			input: libdns.RR{Name: "ttl", Type: "TXT", TTL: 3600 * time.Second},
+			expected: []libdns.Record{
+				libdns.TXT{Name: "ttl", Text: "value_2", TTL: 3600 * time.Second},
+			},
+		},
+		{
+			name:  "exact",
+			input: libdns.TXT{Name: "exact", Text: "value_1", TTL: 3600 * time.Second},
+			expected: []libdns.Record{
+				libdns.TXT{Name: "exact", Text: "value_1", TTL: 3600 * time.Second},
+			},
+		},
+		{
+			name:  "no match",
+			input: libdns.TXT{Name: "exact", Text: "value_2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deleted, err := p.DeleteRecords(context.Background(), "example.com.", []libdns.Record{test.input})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(deleted) != len(test.expected) {
+				t.Fatalf("expected %d deleted records, got %v", len(test.expected), deleted)
+			}
+
+			for _, record := range test.expected {
+				if !contains(deleted, func(actual libdns.Record) bool { return compareRecords(actual, record) }) {
+					t.Fatalf("expected %v to be deleted, got %v", record, deleted)
+				}
+			}
+		})
+	}
+
+	if records := server.records("example.com."); len(records) != 3 {
+		t.Fatalf("expected 3 remaining records, got %v", records)
+	}
+}
+
+func TestProvider_ReturnsStoredRecords(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	appended, err := p.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		libdns.TXT{Name: "append", Text: "value", TTL: 60 * time.Second},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := p.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		libdns.TXT{Name: "set", Text: "value", TTL: 60 * time.Second},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := server.records("example.com.")
+
+	for i, record := range append(appended, set...) {
+		txt, ok := record.(libdns.TXT)
+
+		if !ok {
+			t.Fatalf("expected libdns.TXT, got %T", record)
+		}
+
+		if txt.TTL != 300*time.Second {
+			t.Fatalf("expected TTL of 300s as stored by INWX, got %s", txt.TTL)
+		}
+
+		if data, ok := txt.ProviderData.(ProviderData); !ok || data.ID != stored[i].ID {
+			t.Fatalf("expected provider data with ID %s, got %#v", stored[i].ID, txt.ProviderData)
+		}
+	}
+}
+
+func TestProvider_UsesRecordIDs(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.seed("example.com.",
+		nameserverRecord{Name: "first", Type: "TXT", Content: "value", TTL: 300},
+		nameserverRecord{Name: "second", Type: "TXT", Content: "value", TTL: 300},
+	)
+
+	records, err := p.GetRecords(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := server.records("example.com.")
+
+	for i, record := range records {
+		expected := ProviderData{ID: stored[i].ID, RoID: 1000}
+
+		if data := record.(libdns.TXT).ProviderData; data != expected {
+			t.Fatalf("expected provider data %#v, got %#v", expected, data)
+		}
+	}
+
+	// Renaming the record with its ID moves it instead of creating a new one.
+	renamed := records[0].(libdns.TXT)
+	renamed.Name = "renamed"
+
+	if _, err := p.SetRecords(context.Background(), "example.com.", []libdns.Record{renamed}); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := server.callCount("nameserver.updateRecord"); calls != 1 {
+		t.Fatalf("expected record to be updated by ID, got %d updates", calls)
+	}
+
+	if calls := server.callCount("nameserver.createRecord"); calls != 0 {
+		t.Fatalf("expected no record to be created, got %d creates", calls)
+	}
+
+	infoCalls := server.callCount("nameserver.info")
+	deleted, err := p.DeleteRecords(context.Background(), "example.com.", records[1:])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleted) != 1 {
+		t.Fatalf("expected 1 deleted record, got %v", deleted)
+	}
+
+	if calls := server.callCount("nameserver.info"); calls != infoCalls+1 {
+		t.Fatalf("expected zone to be fetched once, got %d fetches", calls-infoCalls)
+	}
+
+	remaining := server.records("example.com.")
+
+	if len(remaining) != 1 || remaining[0].ID != stored[0].ID || remaining[0].Name != "renamed.example.com" {
+		t.Fatalf("expected only the renamed record to remain, got %v", remaining)
+	}
+}
+
+func TestProvider_RecordIDsOfAllTypes(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.seed("example.com.", nameserverRecord{Name: "_443._tcp", Type: "TLSA", Content: "3 1 1 ABCDEF", TTL: 300})
+
+	records, err := p.GetRecords(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := RR{
+		Name:         "_443._tcp",
+		TTL:          300 * time.Second,
+		Type:         "TLSA",
+		Data:         "3 1 1 ABCDEF",
+		ProviderData: ProviderData{ID: server.records("example.com.")[0].ID, RoID: 1000},
+	}
+
+	if len(records) != 1 || records[0] != expected {
+		t.Fatalf("expected %#v, got %#v", expected, records)
+	}
+
+	appended, err := p.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		libdns.RR{Name: "_25._tcp", TTL: 300 * time.Second, Type: "TLSA", Data: "3 1 1 ABCDEF"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data, ok := providerData(appended[0]); !ok || data.RoID != 1000 {
+		t.Fatalf("expected appended record to carry its ID and the roId of the zone, got %#v", appended[0])
+	}
+
+	deleted, err := p.DeleteRecords(context.Background(), "example.com.", append(records, appended...))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleted) != 2 || len(server.records("example.com.")) != 0 {
+		t.Fatalf("expected both records to be deleted by ID, got %#v", deleted)
+	}
+}
+
+func TestProvider_InvalidStoredRecords(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.seed("example.com.",
+		nameserverRecord{Name: "c", Type: "CAA", Content: "0 issue", TTL: 300},
+		nameserverRecord{Name: "_sip._tcp", Type: "SRV", Content: "sip.example.com", Priority: 10, TTL: 300},
+	)
+
+	records, err := p.GetRecords(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Records which are passed back unchanged are kept as stored.
+	if _, err := p.SetRecords(context.Background(), "example.com.", records[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := server.callCount("nameserver.updateRecord"); calls != 0 {
+		t.Fatalf("expected unchanged record not to be updated, got %d updates", calls)
+	}
+
+	if stored := server.records("example.com."); len(stored) != 2 || stored[1].Priority != 10 {
+		t.Fatalf("expected records to be unchanged, got %+v", stored)
+	}
+
+	deleted, err := p.DeleteRecords(context.Background(), "example.com.", records)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleted) != 2 || len(server.records("example.com.")) != 0 {
+		t.Fatalf("expected both records to be deleted, got %#v", deleted)
+	}
+
+	// Changed data is validated as usual.
+	invalid := records[0].(RR)
+	invalid.Data = "0 issuewild"
+
+	if _, err := p.SetRecords(context.Background(), "example.com.", []libdns.Record{invalid}); err == nil {
+		t.Fatal("expected an error for changed invalid data")
+	}
+}
+
+func TestProvider_DeleteRecordsRejectsIDsOfOtherZones(t *testing.T) {
+	server := newFakeServer(t, "example.com.", "example.org.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.seed("example.org.", nameserverRecord{Name: "test", Type: "TXT", Content: "value", TTL: 300})
+
+	records, err := p.GetRecords(context.Background(), "example.org.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.DeleteRecords(context.Background(), "example.com.", records); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound for record of another zone, got %v", err)
+	}
+
+	// The ID alone does not reveal the zone, but the record is not part of the fetched zone.
+	record := records[0].(libdns.TXT)
+	record.ProviderData = ProviderData{ID: record.ProviderData.(ProviderData).ID}
+
+	if _, err := p.DeleteRecords(context.Background(), "example.com.", []libdns.Record{record}); !errors.Is(err, ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound for record of another zone, got %v", err)
+	}
+
+	if calls := server.callCount("nameserver.deleteRecord"); calls != 0 {
+		t.Fatalf("expected no record to be deleted, got %d deletes", calls)
+	}
+
+	if remaining := server.records("example.org."); len(remaining) != 1 {
+		t.Fatalf("expected record of other zone to remain, got %v", remaining)
+	}
+}
+
+func TestProvider_DeleteRecordsByIDReturnsStoredRecords(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.seed("example.com.", nameserverRecord{Name: "test", Type: "TXT", Content: "stored", TTL: 300})
+
+	records, err := p.GetRecords(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outdated := records[0].(libdns.TXT)
+	outdated.Text = "outdated"
+	outdated.TTL = time.Hour
+
+	deleted, err := p.DeleteRecords(context.Background(), "example.com.", []libdns.Record{outdated, records[0]})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleted) != 1 || !reflect.DeepEqual(deleted[0], records[0]) {
+		t.Fatalf("expected stored record %#v to be deleted once, got %#v", records[0], deleted)
+	}
+}
+
+func TestProvider_AtomicAppendRollsBack(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+	p.Atomic = true
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.fail("nameserver.createRecord", fakeFailure{}, fakeFailure{}, fakeFailure{code: 2306})
+
+	_, err := p.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		libdns.TXT{Name: "first", Text: "value", TTL: 300 * time.Second},
+		libdns.TXT{Name: "second", Text: "value", TTL: 300 * time.Second},
+		libdns.TXT{Name: "third", Text: "value", TTL: 300 * time.Second},
+	})
+
+	if !errors.Is(err, ErrParameterPolicy) || !errors.Is(err, ErrRolledBack) {
+		t.Fatalf("expected rolled back policy error, got %v", err)
+	}
+
+	if records := server.records("example.com."); len(records) != 0 {
+		t.Fatalf("expected created records to be rolled back, got %v", records)
+	}
+
+	// Errors before the first change are returned unchanged.
+	_, err = p.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		libdns.RR{Name: "@", Type: "MX", Data: "mx.example.com"},
+	})
+
+	if err == nil || errors.Is(err, ErrRolledBack) {
+		t.Fatalf("expected invalid record to be rejected without rollback, got %v", err)
+	}
+}
+
+func TestProvider_AtomicSetRollsBack(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+	p.Atomic = true
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.seed("example.com.",
+		nameserverRecord{Name: "first", Type: "TXT", Content: "value_1", TTL: 300},
+		nameserverRecord{Name: "second", Type: "TXT", Content: "value_1", TTL: 300},
+		nameserverRecord{Name: "second", Type: "TXT", Content: "value_2", TTL: 300},
+	)
+
+	original := server.records("example.com.")
+	server.fail("nameserver.deleteRecord", fakeFailure{code: 2304})
+
+	_, err := p.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		libdns.TXT{Name: "first", Text: "value_2", TTL: 300 * time.Second},
+		libdns.TXT{Name: "first", Text: "value_3", TTL: 300 * time.Second},
+		libdns.TXT{Name: "second", Text: "value_3", TTL: 300 * time.Second},
+	})
+
+	if !errors.Is(err, ErrRolledBack) {
+		t.Fatalf("expected changes to be rolled back, got %v", err)
+	}
+
+	records := server.records("example.com.")
+
+	if len(records) != len(original) {
+		t.Fatalf("expected zone to be restored to %v, got %v", original, records)
+	}
+
+	for _, record := range original {
+		if !contains(records, func(actual nameserverRecord) bool { return actual == record }) {
+			t.Fatalf("expected zone to be restored to %v, got %v", original, records)
+		}
+	}
+}
+
+func TestProvider_AtomicReportsRollbackErrors(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+	p.Atomic = true
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.fail("nameserver.createRecord", fakeFailure{}, fakeFailure{code: 2306})
+	server.fail("nameserver.deleteRecord", fakeFailure{code: 2304})
+
+	_, err := p.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		libdns.TXT{Name: "first", Text: "value", TTL: 300 * time.Second},
+		libdns.TXT{Name: "second", Text: "value", TTL: 300 * time.Second},
+	})
+
+	if errors.Is(err, ErrRolledBack) {
+		t.Fatalf("expected rollback to fail, got %v", err)
+	}
+
+	var apiError *APIError
+
+	if !errors.Is(err, ErrParameterPolicy) || !errors.As(err, &apiError) || !strings.Contains(err.Error(), "(2304)") {
+		t.Fatalf("expected both the original and the rollback error, got %v", err)
+	}
+}
+
+func TestProvider_BatchesChanges(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	var unchanged, wildcards []libdns.Record
+
+	for i := 0; i < 100; i++ {
+		name := fmt.Sprintf("record_%d", i)
+		server.seed("example.com.",
+			nameserverRecord{Name: name, Type: "TXT", Content: "value_1", TTL: 300},
+			nameserverRecord{Name: name, Type: "TXT", Content: "value_2", TTL: 300},
+		)
+
+		unchanged = append(unchanged, libdns.TXT{Name: name, Text: "value_1", TTL: 300 * time.Second})
+		wildcards = append(wildcards, libdns.RR{Name: name})
+	}
+
+	infoCalls := server.callCount("nameserver.info")
+
+	if _, err := p.SetRecords(context.Background(), "example.com.", unchanged); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := server.callCount("nameserver.info") - infoCalls; calls != 1 {
+		t.Fatalf("expected zone to be fetched once, got %d calls", calls)
+	}
+
+	if calls := server.callCount("nameserver.deleteRecord"); calls != 1 {
+		t.Fatalf("expected leftover records to be deleted with 1 call, got %d calls", calls)
+	}
+
+	if records := server.records("example.com."); len(records) != 100 {
+		t.Fatalf("expected 100 remaining records, got %d", len(records))
+	}
+
+	deleteCalls := server.callCount("nameserver.deleteRecord")
+	deleted, err := p.DeleteRecords(context.Background(), "example.com.", wildcards)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleted) != 100 {
+		t.Fatalf("expected 100 deleted records, got %d", len(deleted))
+	}
+
+	if calls := server.callCount("nameserver.info") - infoCalls; calls != 2 {
+		t.Fatalf("expected zone to be fetched once per call, got %d calls", calls)
+	}
+
+	if calls := server.callCount("nameserver.deleteRecord") - deleteCalls; calls != 1 {
+		t.Fatalf("expected records to be deleted with 1 call, got %d calls", calls)
+	}
+
+	if calls := server.callCount("nameserver.updateRecord") + server.callCount("nameserver.createRecord"); calls != 0 {
+		t.Fatalf("expected no other changes, got %d calls", calls)
+	}
+}
+
+func TestProvider_ForwardingRecords(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	_, err := p.AppendRecords(context.Background(), "example.com.", []libdns.Record{
+		URL{Name: "go", Target: "https://example.net/", RedirectType: RedirectPermanent, AppendPath: true},
+		Frame{Name: "www", Target: "https://example.net/", Title: "Example", Description: "An example"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := server.records("example.com.")
+
+	if stored[0].RedirectType != "HEADER301" || !stored[0].Append {
+		t.Errorf("expected a permanent redirect with the path appended, got %+v", stored[0])
+	}
+
+	if stored[1].RedirectType != "FRAME" || stored[1].RedirectTitle != "Example" || stored[1].RedirectDescription != "An example" {
+		t.Errorf("expected a frame with title and description, got %+v", stored[1])
+	}
+
+	// Changing only the redirect type updates the record.
+	_, err = p.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		URL{Name: "go", Target: "https://example.net/", RedirectType: RedirectTemporary},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := p.GetRecords(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url, ok := find(records, func(record libdns.Record) bool { return record.RR().Type == "URL" })
+
+	if !ok {
+		t.Fatal("expected the URL record to exist")
+	}
+
+	if url, ok := url.(URL); !ok || url.RedirectType != RedirectTemporary || url.AppendPath {
+		t.Errorf("expected a temporary redirect without the path appended, got %#v", url)
+	}
+
+	frame, _ := find(records, func(record libdns.Record) bool { return record.RR().Type == "FRAME" })
+
+	if frame, ok := frame.(Frame); !ok || frame.Title != "Example" || frame.Target != "https://example.net/" {
+		t.Errorf("expected the frame to be unchanged, got %#v", frame)
+	}
+
+	// Removing an option of the frame clears it.
+	_, err = p.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		Frame{Name: "www", Target: "https://example.net/", Title: "Example"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored = server.records("example.com.")
+
+	if stored[1].RedirectType != "FRAME" || stored[1].RedirectTitle != "Example" || stored[1].RedirectDescription != "" {
+		t.Errorf("expected the description of the frame to be cleared, got %+v", stored[1])
+	}
+
+	// An empty redirect type keeps the current type without updating the record.
+	updates := server.callCount("nameserver.updateRecord")
+
+	_, err = p.SetRecords(context.Background(), "example.com.", []libdns.Record{
+		URL{Name: "go", Target: "https://example.net/"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := server.callCount("nameserver.updateRecord"); calls != updates {
+		t.Errorf("expected no update, got %d", calls-updates)
+	}
+
+	if stored := server.records("example.com."); stored[0].RedirectType != "HEADER302" {
+		t.Errorf("expected the temporary redirect to be kept, got %+v", stored[0])
+	}
+}
+
+func TestProvider_ServiceBindingPriority(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	record := libdns.ServiceBinding{
+		Scheme:   "https",
+		Name:     "www",
+		TTL:      300 * time.Second,
+		Priority: 1,
+		Target:   ".",
+		Params: libdns.SvcParams{
+			"alpn":     {"h3", "h2"},
+			"ech":      {"AEj+DQBE"},
+			"ipv6hint": {"2001:db8::1"},
+			"ipv4hint": {"192.0.2.1"},
+		},
+	}
+
+	_, err := p.AppendRecords(context.Background(), "example.com.", []libdns.Record{record})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := server.records("example.com.")
+
+	if stored[0].Priority != 1 || stored[0].Content != ". alpn=h3,h2 ipv4hint=192.0.2.1 ech=AEj+DQBE ipv6hint=2001:db8::1" {
+		t.Errorf("expected priority 1 and ordered params in the content, got %+v", stored[0])
+	}
+
+	// Setting the same record again does not change anything, regardless of the order of the params.
+	_, err = p.SetRecords(context.Background(), "example.com.", []libdns.Record{record})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := server.callCount("nameserver.updateRecord") + server.callCount("nameserver.createRecord"); calls != 1 {
+		t.Errorf("expected no further changes, got %d calls", calls-1)
+	}
+
+	records, err := p.GetRecords(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, ok := records[0].(libdns.ServiceBinding)
+	result.ProviderData = nil
+
+	if !ok || !reflect.DeepEqual(result, record) {
+		t.Errorf("expected %#v, got %#v", record, records[0])
+	}
+}
+
+func TestProvider_LegacyServiceBinding(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	// Legacy records have been stored with the priority as part of the content.
+	server.seed("example.com.",
+		nameserverRecord{Name: "a", Type: "HTTPS", Content: "1 . alpn=h3,h2", TTL: 300},
+		nameserverRecord{Name: "b", Type: "HTTPS", Content: "1 . port=8443 alpn=h2", TTL: 300},
+	)
+
+	records, err := p.GetRecords(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Setting the record without its ID keeps the legacy record.
+	unchanged := records[1].(libdns.ServiceBinding)
+	unchanged.ProviderData = nil
+
+	if _, err := p.SetRecords(context.Background(), "example.com.", []libdns.Record{unchanged}); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := server.callCount("nameserver.updateRecord") + server.callCount("nameserver.createRecord"); calls != 0 {
+		t.Errorf("expected the legacy record to be kept, got %d changes", calls)
+	}
+
+	var filters []libdns.Record
+
+	for _, record := range records {
+		filter := record.(libdns.ServiceBinding)
+		filter.ProviderData = nil
+		filters = append(filters, filter)
+	}
+
+	deleted, err := p.DeleteRecords(context.Background(), "example.com.", filters)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deleted) != 2 || len(server.records("example.com.")) != 0 {
+		t.Fatalf("expected the legacy records to be deleted by value, got %#v", deleted)
+	}
+}
+
+func TestProvider_CreateAndDeleteZone(t *testing.T) {
+	server := newFakeServer(t)
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	err := p.CreateZone(context.Background(), "example.com.", ZoneOptions{
+		Nameservers: []string{"ns.example.net", "ns2.example.net"},
+		Records:     []libdns.Record{libdns.TXT{Name: "test", Text: "value", TTL: 300 * time.Second}},
+		SOAEmail:    "hostmaster@example.com",
+		Web:         "192.0.2.1",
+		Mail:        "mail.example.com",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := nameserverCreateRequest{
+		Domain:   "example.com",
+		Type:     "MASTER",
+		NS:       []string{"ns.example.net", "ns2.example.net"},
+		SOAEmail: "hostmaster@example.com",
+		Web:      "192.0.2.1",
+		Mail:     "mail.example.com",
+	}
+
+	if request := server.creates["example.com"]; !reflect.DeepEqual(request, expected) {
+		t.Errorf("expected zone to be created with %+v, got %+v", expected, request)
+	}
+
+	if records := server.records("example.com."); len(records) != 1 || records[0].Content != "value" {
+		t.Errorf("expected the initial record to be added, got %+v", records)
+	}
+
+	err = p.CreateZone(context.Background(), "example.com.", ZoneOptions{})
+
+	if !errors.Is(err, ErrObjectExists) {
+		t.Errorf("expected ErrObjectExists when creating the zone again, got %v", err)
+	}
+
+	err = p.DeleteZone(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zones, err := p.ListZones(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(zones) != 0 {
+		t.Errorf("expected no zones after deletion, got %v", zones)
+	}
+}
+
+func TestProvider_CreateZoneDeletesZoneIfRecordsFail(t *testing.T) {
+	server := newFakeServer(t)
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.fail("nameserver.createRecord", fakeFailure{}, fakeFailure{code: 2306})
+
+	err := p.CreateZone(context.Background(), "example.com.", ZoneOptions{
+		Records: []libdns.Record{
+			libdns.TXT{Name: "first", Text: "value", TTL: 300 * time.Second},
+			libdns.TXT{Name: "second", Text: "value", TTL: 300 * time.Second},
+		},
+	})
+
+	if !errors.Is(err, ErrParameterPolicy) {
+		t.Fatalf("expected ErrParameterPolicy, got %v", err)
+	}
+
+	if server.callCount("nameserver.delete") != 1 {
+		t.Errorf("expected the zone to be deleted again")
+	}
+
+	if _, ok := server.zones["example.com"]; ok {
+		t.Errorf("expected the zone not to exist anymore")
+	}
+}
+
+func TestProvider_SlaveZones(t *testing.T) {
+	server := newFakeServer(t, "master.example.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	err := p.CreateZone(context.Background(), "slave.example.", ZoneOptions{Type: ZoneTypeSlave})
+
+	if err == nil {
+		t.Fatal("expected an error when creating a slave zone without master IPs")
+	}
+
+	err = p.CreateZone(context.Background(), "slave.example.", ZoneOptions{
+		Type:      ZoneTypeSlave,
+		MasterIPs: []string{"192.0.2.1", "2001:db8::1"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if masterIP := server.creates["slave.example"].MasterIP; masterIP != "192.0.2.1,2001:db8::1" {
+		t.Errorf("expected master IPs to be sent, got %q", masterIP)
+	}
+
+	err = p.UpdateSlaveZone(context.Background(), "slave.example.", []string{"192.0.2.2"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zones, err := p.ListSlaveZones(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SlaveZone{{Name: "slave.example", MasterIPs: []string{"192.0.2.2"}}}
+
+	if !reflect.DeepEqual(zones, expected) {
+		t.Errorf("expected %+v, got %+v", expected, zones)
+	}
+
+	err = p.TransferZone(context.Background(), "slave.example.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := libdns.TXT{Name: "test", Text: "value", TTL: 300 * time.Second}
+
+	if _, err := p.AppendRecords(context.Background(), "slave.example.", []libdns.Record{record}); !errors.Is(err, ErrSlaveZone) {
+		t.Errorf("expected AppendRecords to fail with ErrSlaveZone, got %v", err)
+	}
+
+	if _, err := p.SetRecords(context.Background(), "slave.example.", []libdns.Record{record}); !errors.Is(err, ErrSlaveZone) {
+		t.Errorf("expected SetRecords to fail with ErrSlaveZone, got %v", err)
+	}
+
+	if _, err := p.DeleteRecords(context.Background(), "slave.example.", []libdns.Record{record}); !errors.Is(err, ErrSlaveZone) {
+		t.Errorf("expected DeleteRecords to fail with ErrSlaveZone, got %v", err)
+	}
+
+	if _, err := p.AppendRecords(context.Background(), "master.example.", []libdns.Record{record}); err != nil {
+		t.Errorf("expected records of master zones to be modifiable, got %v", err)
+	}
+}
+
+func TestProvider_ListZoneDetails(t *testing.T) {
+	server := newFakeServer(t, "example.com.", "example.net.", "other.org.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.seed("example.com.",
+		nameserverRecord{Name: "@", Type: "NS", Content: "ns.inwx.de", TTL: 86400},
+		nameserverRecord{Name: "@", Type: "NS", Content: "ns2.inwx.de", TTL: 86400},
+		nameserverRecord{Name: "sub", Type: "NS", Content: "ns.example.net", TTL: 86400},
+		nameserverRecord{Name: "www", Type: "A", Content: "192.0.2.1", TTL: 300},
+	)
+	server.dnssec["example.com"] = "AUTO"
+
+	zones, err := p.ListZoneDetails(context.Background(), "example.*", ZoneDetailsOptions{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.SortFunc(zones, func(a ZoneDetails, b ZoneDetails) int { return strings.Compare(a.Name, b.Name) })
+
+	expected := []ZoneDetails{
+		{Name: "example.com", RoID: 1000, Type: ZoneTypeMaster, DNSSECStatus: "AUTO"},
+		{Name: "example.net", RoID: 1001, Type: ZoneTypeMaster},
+	}
+
+	if !reflect.DeepEqual(zones, expected) {
+		t.Errorf("expected %+v, got %+v", expected, zones)
+	}
+
+	if calls := server.callCount("nameserver.info"); calls != 0 {
+		t.Errorf("expected no zone to be fetched without FetchRecords, got %d calls", calls)
+	}
+
+	zones, err = p.ListZoneDetails(context.Background(), "example.*", ZoneDetailsOptions{FetchRecords: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.SortFunc(zones, func(a ZoneDetails, b ZoneDetails) int { return strings.Compare(a.Name, b.Name) })
+
+	expected = []ZoneDetails{
+		{
+			Name:         "example.com",
+			RoID:         1000,
+			Type:         ZoneTypeMaster,
+			RecordCount:  4,
+			DNSSECStatus: "AUTO",
+			Nameservers:  []string{"ns.inwx.de", "ns2.inwx.de"},
+		},
+		{
+			Name: "example.net",
+			RoID: 1001,
+			Type: ZoneTypeMaster,
+		},
+	}
+
+	if !reflect.DeepEqual(zones, expected) {
+		t.Errorf("expected %+v, got %+v", expected, zones)
+	}
+
+	if calls := server.callCount("nameserver.info"); calls != 2 {
+		t.Errorf("expected only the matching zones to be fetched, got %d calls", calls)
+	}
+}
+
+func TestProvider_UpdateSOA(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	server.seed("example.com.", nameserverRecord{
+		Name:    "@",
+		Type:    "SOA",
+		Content: "ns.inwx.de hostmaster.inwx.de 2024010101 10800 3600 604800 3600",
+		TTL:     86400,
+	})
+
+	soa, err := p.GetSOA(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := SOA{
+		Nameserver: "ns.inwx.de",
+		Email:      "hostmaster@inwx.de",
+		Serial:     2024010101,
+		Refresh:    3 * time.Hour,
+		Retry:      time.Hour,
+		Expire:     7 * 24 * time.Hour,
+		MinimumTTL: time.Hour,
+		TTL:        24 * time.Hour,
+	}
+
+	if soa != expected {
+		t.Fatalf("expected %+v, got %+v", expected, soa)
+	}
+
+	soa.Email = "dns.admin@example.com"
+	soa.Refresh = 15 * time.Minute
+	soa.Retry = 5 * time.Minute
+	soa.MinimumTTL = 5 * time.Minute
+	soa.TTL = 0
+
+	err = p.UpdateSOA(context.Background(), "example.com.", soa, SerialIncrement)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := server.records("example.com.")[0]
+
+	if record.Content != `ns.inwx.de dns\.admin.example.com 2024010102 900 300 604800 300` || record.TTL != 86400 {
+		t.Errorf("unexpected SOA record %+v", record)
+	}
+
+	soa.Retry = time.Hour
+
+	if err := p.UpdateSOA(context.Background(), "example.com.", soa, SerialIncrement); err == nil {
+		t.Error("expected an error for a retry interval longer than the refresh interval")
+	}
+
+	if calls := server.callCount("nameserver.updateRecord"); calls != 1 {
+		t.Errorf("expected invalid SOA values not to be sent, got %d updates", calls)
+	}
+}
+
+func TestProvider_DNSSEC(t *testing.T) {
+	server := newFakeServer(t, "example.com.")
+	p := server.provider()
+
+	t.Cleanup(func() {
+		p.Close(context.Background())
+	})
+
+	status, err := p.GetDNSSECStatus(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Enabled {
+		t.Errorf("expected DNSSEC to be disabled, got %+v", status)
+	}
+
+	var apiError *APIError
+
+	if err := p.RolloverDNSSECKeys(context.Background(), "example.com."); !errors.As(err, &apiError) || apiError.Code != 2304 {
+		t.Errorf("expected the rollover to fail while DNSSEC is disabled, got %v", err)
+	}
+
+	err = p.EnableDNSSEC(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err = p.GetDNSSECStatus(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := (DNSSECStatus{Enabled: true, Status: "AUTO", KeyCount: 1}); status != expected {
+		t.Errorf("expected %+v, got %+v", expected, status)
+	}
+
+	keys, err := p.ListDNSSECKeys(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 2 || !keys[0].IsKSK() || keys[1].IsKSK() {
+		t.Fatalf("expected a KSK and a ZSK, got %+v", keys)
+	}
+
+	if dnskey := keys[1].DNSKEY(); dnskey.Data != "256 3 13 ZSK" {
+		t.Errorf("unexpected DNSKEY record %+v", dnskey)
+	}
+
+	err = p.RolloverDNSSECKeys(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := p.ListDSRecords(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []libdns.RR{{Name: "@", Type: "DS", Data: fmt.Sprintf("%d 13 2 ABCDEF", keys[0].KeyTag+2)}}
+
+	if !reflect.DeepEqual(ds, expected) {
+		t.Errorf("expected the DS record of the new KSK %+v, got %+v", expected, ds)
+	}
+
+	if _, err := ds[0].Parse(); err != nil {
+		t.Errorf("expected a valid DS record, got %v", err)
+	}
+
+	err = p.DisableDNSSEC(context.Background(), "example.com.")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err = p.ListDNSSECKeys(context.Background(), "example.com.")
+
+	if err != nil || len(keys) != 0 {
+		t.Errorf("expected no keys after disabling DNSSEC, got %+v, %v", keys, err)
+	}
+}
@@ -83,13 +83,18 @@ func compareRecords(lhs libdns.Record, rhs libdns.Record) bool {
 
 func createTestNameserver(p *Provider) error {
 	client, err := p.getClient(context.TODO())
-	defer p.removeClient(context.TODO())
 
 	if err != nil {
 		return err
 	}
 
-	err = client.createNameserver(context.TODO(), getDomain(zone), "MASTER", []string{"ns.ote.inwx.de", "ns2.ote.inwx.de"})
+	defer p.releaseClient(context.TODO(), client)
+
+	err = client.createNameserver(context.TODO(), nameserverCreateRequest{
+		Domain: getDomain(zone),
+		Type:   "MASTER",
+		NS:     []string{"ns.ote.inwx.de", "ns2.ote.inwx.de"},
+	})
 
 	if err != nil {
 		return err
@@ -102,12 +107,13 @@ func createTestNameserver(p *Provider) error {
 
 func deleteTestNameserver(p *Provider) error {
 	client, err := p.getClient(context.TODO())
-	defer p.removeClient(context.TODO())
 
 	if err != nil {
 		return err
 	}
 
+	defer p.releaseClient(context.TODO(), client)
+
 	return client.deleteNameserver(context.TODO(), getDomain(zone))
 }
 
@@ -363,3 +369,143 @@ func TestProvider_ListZones(t *testing.T) {
 		t.Fatalf("expected zone %q not found in listed zones: %v", getDomain(zone), zones)
 	}
 }
+
+func TestProvider_CreateZone(t *testing.T) {
+	p := getProvider()
+
+	err := p.CreateZone(context.Background(), zone, ZoneOptions{
+		Nameservers: []string{"ns.ote.inwx.de", "ns2.ote.inwx.de"},
+		Records:     testRecords,
+	})
+
+	t.Cleanup(func() {
+		err = deleteTestNameserver(p)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := p.GetRecords(context.Background(), zone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testRecord := range testRecords {
+		found := contains(records, func(record libdns.Record) bool {
+			return compareRecords(record, testRecord)
+		})
+
+		if !found {
+			t.Fatalf("expected record %#v not found in created zone", testRecord)
+		}
+	}
+}
+
+func TestProvider_DeleteZone(t *testing.T) {
+	p := getProvider()
+
+	err := createTestNameserver(p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.DeleteZone(context.Background(), zone)
+
+	if err != nil {
+		deleteTestNameserver(p)
+		t.Fatal(err)
+	}
+
+	zones, err := p.ListZones(context.Background())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := contains(zones, func(z libdns.Zone) bool {
+		return z.Name == getDomain(zone)
+	})
+
+	if found {
+		t.Fatalf("expected zone %q to be deleted, but it is still listed", getDomain(zone))
+	}
+}
+
+func TestProvider_EnableAndDisableDNSSEC(t *testing.T) {
+	p := getProvider()
+
+	err := createTestNameserver(p)
+
+	t.Cleanup(func() {
+		err = deleteTestNameserver(p)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.EnableDNSSEC(context.Background(), zone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := p.GetDNSSECStatus(context.Background(), zone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !status.Enabled {
+		t.Fatalf("expected DNSSEC to be enabled, got %+v", status)
+	}
+
+	keys, err := p.ListDNSSECKeys(context.Background(), zone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) == 0 {
+		t.Fatal("expected keys after enabling DNSSEC, got none")
+	}
+
+	_, err = p.ListDSRecords(context.Background(), zone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.RolloverDNSSECKeys(context.Background(), zone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = p.DisableDNSSEC(context.Background(), zone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err = p.GetDNSSECStatus(context.Background(), zone)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Enabled {
+		t.Fatalf("expected DNSSEC to be disabled, got %+v", status)
+	}
+}
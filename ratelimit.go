@@ -0,0 +1,83 @@
+package inwx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket which limits the rate of requests to the API.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// Rate limiters are shared by all clients using the same account, because INWX enforces its
+// request limits per account.
+var (
+	rateLimiters   = map[string]*rateLimiter{}
+	rateLimitersMu sync.Mutex
+)
+
+// sharedRateLimiter returns the rate limiter for the account at the endpoint. The limits are
+// updated to the given values if the rate limiter already exists.
+func sharedRateLimiter(endpointURL string, username string, rate float64, burst int) *rateLimiter {
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	key := endpointURL + "\x00" + username
+	limiter, ok := rateLimiters[key]
+
+	if !ok {
+		limiter = &rateLimiter{tokens: float64(burst), last: time.Now()}
+		rateLimiters[key] = limiter
+	}
+
+	limiter.mu.Lock()
+	limiter.rate = rate
+	limiter.burst = float64(burst)
+	limiter.mu.Unlock()
+
+	return limiter
+}
+
+// wait blocks until a request may be sent or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	// Reserve the token, even if it is not available yet, so that concurrent requests queue up
+	// behind each other.
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
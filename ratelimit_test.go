@@ -0,0 +1,57 @@
+package inwx
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSharedRateLimiter(t *testing.T) {
+	a := sharedRateLimiter("https://example.com/", "shared", 50, 1)
+	b := sharedRateLimiter("https://example.com/", "shared", 50, 1)
+	c := sharedRateLimiter("https://example.com/", "other", 50, 1)
+
+	t.Cleanup(func() {
+		rateLimitersMu.Lock()
+		defer rateLimitersMu.Unlock()
+
+		delete(rateLimiters, "https://example.com/\x00shared")
+		delete(rateLimiters, "https://example.com/\x00other")
+	})
+
+	if a != b {
+		t.Fatal("expected providers of the same account to share a rate limiter")
+	}
+
+	if a == c {
+		t.Fatal("expected providers of different accounts to use separate rate limiters")
+	}
+
+	start := time.Now()
+
+	for i := 0; i < 6; i++ {
+		if err := a.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first request uses the burst, the other five have to wait 20ms each.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("expected requests to be limited, but 6 requests took only %s", elapsed)
+	}
+}
+
+func TestRateLimiter_HonorsContext(t *testing.T) {
+	limiter := &rateLimiter{rate: 0.1, burst: 1, tokens: 1, last: time.Now()}
+
+	if err := limiter.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline to be exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,497 @@
+package inwx
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+// ProviderData is set as the ProviderData of records returned by the provider. When such records
+// are passed to SetRecords or DeleteRecords, the record with the ID is modified directly.
+type ProviderData struct {
+	// ID of the record at INWX.
+	ID string
+
+	// ID of the zone at INWX (roId).
+	RoID int
+}
+
+// RR is a record of a type for which libdns has no type, such as TLSA or SSHFP. It is returned
+// instead of [libdns.RR], which has no field for [ProviderData].
+type RR struct {
+	Name string
+	TTL  time.Duration
+	Type string
+
+	// Data of the record in zone file presentation format.
+	Data string
+
+	// Optional custom data associated with the provider serving this record. Records returned by
+	// the provider carry a [ProviderData] value.
+	ProviderData any
+}
+
+// RR returns the record as a [libdns.RR].
+func (r RR) RR() libdns.RR {
+	return libdns.RR{
+		Name: r.Name,
+		TTL:  r.TTL,
+		Type: r.Type,
+		Data: r.Data,
+	}
+}
+
+// recordConversion converts between the data of a libdns record, which is in zone file
+// presentation format, and the content and priority of an INWX record.
+type recordConversion struct {
+	toData   func(content string, priority uint) (string, error)
+	fromData func(data string) (content string, priority uint, err error)
+}
+
+// Conversions of all record types supported by INWX. Types which are not listed are passed through
+// unchanged.
+// https://www.inwx.de/en/help/apidoc/f/ch03.html#type.recordtype
+var recordConversions = map[string]recordConversion{
+	"A":          plainRecord,
+	"AAAA":       plainRecord,
+	"AFSDB":      fieldsRecord(2, 0),
+	"ALIAS":      plainRecord,
+	"CAA":        caaRecord,
+	"CERT":       fieldsRecord(4, 1),
+	"CNAME":      plainRecord,
+	"DNSKEY":     fieldsRecord(4, 0, 1, 2),
+	"DS":         fieldsRecord(4, 0, 1, 2),
+	"FRAME":      plainRecord,
+	"HINFO":      fieldsRecord(2),
+	"HTTPS":      serviceBindingRecord,
+	"LOC":        fieldsRecord(4),
+	"MX":         priorityRecord(1),
+	"NAPTR":      fieldsRecord(6, 0, 1),
+	"NS":         plainRecord,
+	"OPENPGPKEY": plainRecord,
+	"PTR":        plainRecord,
+	"RP":         fieldsRecord(2),
+	"SMIMEA":     fieldsRecord(4, 0, 1, 2),
+	"SOA":        fieldsRecord(7, 2, 3, 4, 5, 6),
+	"SRV":        priorityRecord(3),
+	"SSHFP":      fieldsRecord(3, 0, 1),
+	"SVCB":       serviceBindingRecord,
+	"TLSA":       fieldsRecord(4, 0, 1, 2),
+	"TXT":        plainRecord,
+	"URL":        plainRecord,
+}
+
+// plainRecord stores the data unchanged as content.
+var plainRecord = recordConversion{
+	toData: func(content string, _ uint) (string, error) {
+		return content, nil
+	},
+	fromData: func(data string) (string, uint, error) {
+		return data, 0, nil
+	},
+}
+
+// fieldsRecord stores the data unchanged as content after validating that it consists of at least
+// the given number of fields, of which the fields at the given indices are numeric.
+func fieldsRecord(count int, numeric ...int) recordConversion {
+	validate := func(data string) error {
+		fields, err := splitFields(data)
+
+		if err != nil {
+			return err
+		}
+
+		if len(fields) < count {
+			return fmt.Errorf("expected at least %d fields in %q", count, data)
+		}
+
+		for _, index := range numeric {
+			if _, err := strconv.ParseUint(fields[index], 10, 32); err != nil {
+				return fmt.Errorf("expected field %d of %q to be numeric", index+1, data)
+			}
+		}
+
+		return nil
+	}
+
+	return recordConversion{
+		toData: func(content string, _ uint) (string, error) {
+			return content, validate(content)
+		},
+		fromData: func(data string) (string, uint, error) {
+			return data, 0, validate(data)
+		},
+	}
+}
+
+// priorityRecord stores the first field of the data, e.g. the preference of MX records, as
+// priority and the remaining fields, of which there must be at least the given number, as content.
+func priorityRecord(count int) recordConversion {
+	return recordConversion{
+		toData: func(content string, priority uint) (string, error) {
+			if len(strings.Fields(content)) < count {
+				return "", fmt.Errorf("expected at least %d fields in %q", count, content)
+			}
+
+			return fmt.Sprintf("%d %s", priority, content), nil
+		},
+		fromData: func(data string) (string, uint, error) {
+			priority, content, _ := strings.Cut(strings.TrimSpace(data), " ")
+			value, err := strconv.ParseUint(priority, 10, 16)
+
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid priority %q: %v", priority, err)
+			}
+
+			content = strings.TrimSpace(content)
+
+			if len(strings.Fields(content)) < count {
+				return "", 0, fmt.Errorf("expected at least %d fields after the priority in %q", count, data)
+			}
+
+			return content, uint(value), nil
+		},
+	}
+}
+
+// caaRecord stores the data as content with the value in quotes, as does libdns.
+var caaRecord = recordConversion{
+	toData: func(content string, _ uint) (string, error) {
+		return normalizeCAA(content)
+	},
+	fromData: func(data string) (string, uint, error) {
+		content, err := normalizeCAA(data)
+
+		return content, 0, err
+	},
+}
+
+func normalizeCAA(data string) (string, error) {
+	fields := strings.SplitN(strings.TrimSpace(data), " ", 3)
+
+	if len(fields) != 3 {
+		return "", fmt.Errorf(`expected CAA value in the form 'flags tag "value"': %q`, data)
+	}
+
+	flags, err := strconv.ParseUint(fields[0], 10, 8)
+
+	if err != nil {
+		return "", fmt.Errorf("invalid CAA flags %q: %v", fields[0], err)
+	}
+
+	value, err := strconv.Unquote(fields[2])
+
+	if err != nil {
+		value = fields[2]
+	}
+
+	return fmt.Sprintf("%d %s %q", flags, fields[1], value), nil
+}
+
+// serviceBindingRecord stores the priority of HTTPS and SVCB records as priority and the target
+// followed by the parameters as content. The parameters are ordered by their key as recommended by
+// RFC 9460, so that the content of equal records is equal.
+//
+// Records with priority 0 whose content starts with a number have been created with the priority
+// as part of the content, which is accepted for compatibility.
+var serviceBindingRecord = recordConversion{
+	toData: func(content string, priority uint) (string, error) {
+		fields := strings.Fields(content)
+
+		if priority == 0 && len(fields) >= 2 {
+			if _, err := strconv.ParseUint(fields[0], 10, 16); err == nil {
+				return content, nil
+			}
+		}
+
+		if len(fields) == 0 {
+			return "", fmt.Errorf("expected a target in %q", content)
+		}
+
+		return fmt.Sprintf("%d %s", priority, content), nil
+	},
+	fromData: func(data string) (string, uint, error) {
+		fields := strings.SplitN(strings.TrimSpace(data), " ", 3)
+
+		if len(fields) < 2 {
+			return "", 0, fmt.Errorf("expected a value in the form 'priority target [params]': %q", data)
+		}
+
+		priority, err := strconv.ParseUint(fields[0], 10, 16)
+
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid priority %q: %v", fields[0], err)
+		}
+
+		content := fields[1]
+
+		if len(fields) == 3 {
+			params, err := libdns.ParseSvcParams(fields[2])
+
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid params %q: %v", fields[2], err)
+			}
+
+			if formatted := formatSvcParams(params); formatted != "" {
+				content += " " + formatted
+			}
+		}
+
+		return content, uint(priority), nil
+	},
+}
+
+// Numbers of the service parameter keys registered by RFC 9460, which determine their order.
+var svcParamKeys = map[string]int{
+	"mandatory":       0,
+	"alpn":            1,
+	"no-default-alpn": 2,
+	"port":            3,
+	"ipv4hint":        4,
+	"ech":             5,
+	"ipv6hint":        6,
+}
+
+// formatSvcParams formats the parameters in ascending order of their key numbers. Unknown keys
+// are placed last in alphabetical order.
+func formatSvcParams(params libdns.SvcParams) string {
+	keys := make([]string, 0, len(params))
+
+	for key := range params {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a string, b string) int {
+		return cmp.Or(cmp.Compare(svcParamKeyNumber(a), svcParamKeyNumber(b)), strings.Compare(a, b))
+	})
+
+	formatted := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		// A single parameter is formatted deterministically, unlike the whole map.
+		formatted = append(formatted, libdns.SvcParams{key: params[key]}.String())
+	}
+
+	return strings.Join(formatted, " ")
+}
+
+func svcParamKeyNumber(key string) int {
+	if number, ok := svcParamKeys[key]; ok {
+		return number
+	}
+
+	if number, err := strconv.ParseUint(strings.TrimPrefix(key, "key"), 10, 16); err == nil && strings.HasPrefix(key, "key") {
+		return int(number)
+	}
+
+	return 1 << 16
+}
+
+// splitFields splits the data into fields separated by whitespace. Quoted strings form a single
+// field, including the quotes.
+func splitFields(data string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	var quoted, escaped bool
+
+	for _, r := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && (r == ' ' || r == '\t'):
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+
+			continue
+		}
+
+		field.WriteRune(r)
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated quoted string in %q", data)
+	}
+
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+
+	return fields, nil
+}
+
+func getRecordConversion(recordType string) recordConversion {
+	if conversion, ok := recordConversions[recordType]; ok {
+		return conversion
+	}
+
+	return plainRecord
+}
+
+// libdnsRecord converts an INWX record into the corresponding libdns type, into [URL] or [Frame]
+// for web forwarding records, or into a [libdns.RR] if libdns has no type for the record type.
+// Records whose content cannot be parsed are returned as an [RR] with the unparsed content, so that
+// a single record which INWX accepted does not prevent reading the rest of the zone.
+func libdnsRecord(record nameserverRecord, zone string, roID int) libdns.Record {
+	name := libdns.RelativeName(record.Name, getDomain(zone))
+	ttl := time.Duration(record.TTL) * time.Second
+
+	if result, ok := forwardingRecord(record, name, ttl); ok {
+		if record.ID == "" {
+			return result
+		}
+
+		return withProviderData(result, ProviderData{ID: record.ID, RoID: roID})
+	}
+
+	data, err := getRecordConversion(record.Type).toData(record.Content, record.Priority)
+
+	if err != nil {
+		data = record.Content
+	}
+
+	rr := libdns.RR{
+		Type: record.Type,
+		Name: name,
+		Data: data,
+		TTL:  ttl,
+	}
+
+	result, err := rr.Parse()
+
+	if err != nil {
+		result = rr
+	}
+
+	if record.ID == "" {
+		return result
+	}
+
+	return withProviderData(result, ProviderData{ID: record.ID, RoID: roID})
+}
+
+// inwxRecord converts a libdns record into an INWX record. Records with empty data, which act as
+// wildcards in DeleteRecords, are converted into records with empty content.
+func inwxRecord(record libdns.Record) (nameserverRecord, error) {
+	rr := record.RR()
+
+	inwxRecord := nameserverRecord{
+		Name: rr.Name,
+		Type: rr.Type,
+		TTL:  int(rr.TTL.Seconds()),
+
+		urlRedirect: getURLRedirect(record),
+	}
+
+	if data, ok := providerData(record); ok {
+		inwxRecord.ID = data.ID
+	}
+
+	if rr.Data == "" {
+		return inwxRecord, nil
+	}
+
+	content, priority, err := getRecordConversion(rr.Type).fromData(rr.Data)
+
+	if err != nil {
+		return nameserverRecord{}, fmt.Errorf("invalid %s record %s: %w", rr.Type, rr.Name, err)
+	}
+
+	inwxRecord.Content = content
+	inwxRecord.Priority = priority
+
+	return inwxRecord, nil
+}
+
+// providerData returns the provider data of the record, if it has been returned by the provider.
+func providerData(record libdns.Record) (ProviderData, bool) {
+	var data any
+
+	switch rec := record.(type) {
+	case libdns.Address:
+		data = rec.ProviderData
+	case libdns.CAA:
+		data = rec.ProviderData
+	case libdns.CNAME:
+		data = rec.ProviderData
+	case libdns.MX:
+		data = rec.ProviderData
+	case libdns.NS:
+		data = rec.ProviderData
+	case libdns.SRV:
+		data = rec.ProviderData
+	case libdns.ServiceBinding:
+		data = rec.ProviderData
+	case libdns.TXT:
+		data = rec.ProviderData
+	case URL:
+		data = rec.ProviderData
+	case Frame:
+		data = rec.ProviderData
+	case RR:
+		data = rec.ProviderData
+	}
+
+	providerData, ok := data.(ProviderData)
+
+	return providerData, ok && providerData.ID != ""
+}
+
+// withProviderData sets the provider data of the record, if its type has such a field.
+func withProviderData(record libdns.Record, data ProviderData) libdns.Record {
+	switch rec := record.(type) {
+	case libdns.Address:
+		rec.ProviderData = data
+		return rec
+	case libdns.CAA:
+		rec.ProviderData = data
+		return rec
+	case libdns.CNAME:
+		rec.ProviderData = data
+		return rec
+	case libdns.MX:
+		rec.ProviderData = data
+		return rec
+	case libdns.NS:
+		rec.ProviderData = data
+		return rec
+	case libdns.SRV:
+		rec.ProviderData = data
+		return rec
+	case libdns.ServiceBinding:
+		rec.ProviderData = data
+		return rec
+	case libdns.TXT:
+		rec.ProviderData = data
+		return rec
+	case URL:
+		rec.ProviderData = data
+		return rec
+	case Frame:
+		rec.ProviderData = data
+		return rec
+	case RR:
+		rec.ProviderData = data
+		return rec
+	case libdns.RR:
+		return RR{
+			Name:         rec.Name,
+			TTL:          rec.TTL,
+			Type:         rec.Type,
+			Data:         rec.Data,
+			ProviderData: data,
+		}
+	}
+
+	return record
+}
@@ -0,0 +1,262 @@
+package inwx
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestRecordConversion(t *testing.T) {
+	tests := []struct {
+		name   string
+		inwx   nameserverRecord
+		libdns libdns.Record
+	}{
+		{
+			name:   "A",
+			inwx:   nameserverRecord{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 300},
+			libdns: libdns.Address{Name: "www", TTL: 300 * time.Second, IP: netip.MustParseAddr("192.0.2.1")},
+		},
+		{
+			name:   "AAAA",
+			inwx:   nameserverRecord{Name: "www.example.com", Type: "AAAA", Content: "2001:db8::1", TTL: 300},
+			libdns: libdns.Address{Name: "www", TTL: 300 * time.Second, IP: netip.MustParseAddr("2001:db8::1")},
+		},
+		{
+			name:   "AFSDB",
+			inwx:   nameserverRecord{Name: "example.com", Type: "AFSDB", Content: "1 afs.example.com", TTL: 300},
+			libdns: libdns.RR{Name: "@", TTL: 300 * time.Second, Type: "AFSDB", Data: "1 afs.example.com"},
+		},
+		{
+			name:   "ALIAS",
+			inwx:   nameserverRecord{Name: "example.com", Type: "ALIAS", Content: "target.example.net", TTL: 300},
+			libdns: libdns.RR{Name: "@", TTL: 300 * time.Second, Type: "ALIAS", Data: "target.example.net"},
+		},
+		{
+			name:   "CAA",
+			inwx:   nameserverRecord{Name: "example.com", Type: "CAA", Content: `0 issue "letsencrypt.org"`, TTL: 300},
+			libdns: libdns.CAA{Name: "@", TTL: 300 * time.Second, Flags: 0, Tag: "issue", Value: "letsencrypt.org"},
+		},
+		{
+			name:   "CERT",
+			inwx:   nameserverRecord{Name: "example.com", Type: "CERT", Content: "PGP 0 0 AQID", TTL: 300},
+			libdns: libdns.RR{Name: "@", TTL: 300 * time.Second, Type: "CERT", Data: "PGP 0 0 AQID"},
+		},
+		{
+			name:   "CNAME",
+			inwx:   nameserverRecord{Name: "www.example.com", Type: "CNAME", Content: "example.com", TTL: 300},
+			libdns: libdns.CNAME{Name: "www", TTL: 300 * time.Second, Target: "example.com"},
+		},
+		{
+			name:   "DNSKEY",
+			inwx:   nameserverRecord{Name: "example.com", Type: "DNSKEY", Content: "257 3 13 AQID", TTL: 300},
+			libdns: libdns.RR{Name: "@", TTL: 300 * time.Second, Type: "DNSKEY", Data: "257 3 13 AQID"},
+		},
+		{
+			name:   "DS",
+			inwx:   nameserverRecord{Name: "sub.example.com", Type: "DS", Content: "12345 13 2 ABCDEF", TTL: 300},
+			libdns: libdns.RR{Name: "sub", TTL: 300 * time.Second, Type: "DS", Data: "12345 13 2 ABCDEF"},
+		},
+		{
+			name: "FRAME",
+			inwx: nameserverRecord{Name: "www.example.com", Type: "FRAME", Content: "https://example.net", TTL: 300, urlRedirect: urlRedirect{
+				RedirectType:        "FRAME",
+				RedirectTitle:       "Example",
+				RedirectDescription: "An example",
+				RedirectKeywords:    "example,test",
+				RedirectFavIcon:     "https://example.net/favicon.ico",
+			}},
+			libdns: Frame{Name: "www", TTL: 300 * time.Second, Target: "https://example.net", Title: "Example", Description: "An example", Keywords: "example,test", FavIcon: "https://example.net/favicon.ico"},
+		},
+		{
+			name:   "HINFO",
+			inwx:   nameserverRecord{Name: "example.com", Type: "HINFO", Content: `"Intel" "Linux"`, TTL: 300},
+			libdns: libdns.RR{Name: "@", TTL: 300 * time.Second, Type: "HINFO", Data: `"Intel" "Linux"`},
+		},
+		{
+			name: "HTTPS",
+			inwx: nameserverRecord{Name: "example.com", Type: "HTTPS", Content: ". alpn=h3,h2 port=8443 ech=AEj+DQBE ipv6hint=2001:db8::1,2001:db8::2", TTL: 300, Priority: 1},
+			libdns: libdns.ServiceBinding{Scheme: "https", Name: "@", TTL: 300 * time.Second, Priority: 1, Target: ".", Params: libdns.SvcParams{
+				"alpn":     {"h3", "h2"},
+				"port":     {"8443"},
+				"ech":      {"AEj+DQBE"},
+				"ipv6hint": {"2001:db8::1", "2001:db8::2"},
+			}},
+		},
+		{
+			name:   "LOC",
+			inwx:   nameserverRecord{Name: "example.com", Type: "LOC", Content: "52 22 23.000 N 4 53 32.000 E -2.00m 0.00m 10000m 10m", TTL: 300},
+			libdns: libdns.RR{Name: "@", TTL: 300 * time.Second, Type: "LOC", Data: "52 22 23.000 N 4 53 32.000 E -2.00m 0.00m 10000m 10m"},
+		},
+		{
+			name:   "MX",
+			inwx:   nameserverRecord{Name: "example.com", Type: "MX", Content: "mx.example.com", TTL: 300, Priority: 10},
+			libdns: libdns.MX{Name: "@", TTL: 300 * time.Second, Preference: 10, Target: "mx.example.com"},
+		},
+		{
+			name:   "NAPTR",
+			inwx:   nameserverRecord{Name: "example.com", Type: "NAPTR", Content: `100 10 "S" "SIP+D2U" "" _sip._udp.example.com.`, TTL: 300},
+			libdns: libdns.RR{Name: "@", TTL: 300 * time.Second, Type: "NAPTR", Data: `100 10 "S" "SIP+D2U" "" _sip._udp.example.com.`},
+		},
+		{
+			name:   "NS",
+			inwx:   nameserverRecord{Name: "sub.example.com", Type: "NS", Content: "ns.example.net", TTL: 300},
+			libdns: libdns.NS{Name: "sub", TTL: 300 * time.Second, Target: "ns.example.net"},
+		},
+		{
+			name:   "OPENPGPKEY",
+			inwx:   nameserverRecord{Name: "hash._openpgpkey.example.com", Type: "OPENPGPKEY", Content: "AQID", TTL: 300},
+			libdns: libdns.RR{Name: "hash._openpgpkey", TTL: 300 * time.Second, Type: "OPENPGPKEY", Data: "AQID"},
+		},
+		{
+			name:   "PTR",
+			inwx:   nameserverRecord{Name: "1.example.com", Type: "PTR", Content: "host.example.com", TTL: 300},
+			libdns: libdns.RR{Name: "1", TTL: 300 * time.Second, Type: "PTR", Data: "host.example.com"},
+		},
+		{
+			name:   "RP",
+			inwx:   nameserverRecord{Name: "example.com", Type: "RP", Content: "admin.example.com txt.example.com", TTL: 300},
+			libdns: libdns.RR{Name: "@", TTL: 300 * time.Second, Type: "RP", Data: "admin.example.com txt.example.com"},
+		},
+		{
+			name:   "SMIMEA",
+			inwx:   nameserverRecord{Name: "hash._smimecert.example.com", Type: "SMIMEA", Content: "3 1 1 ABCDEF", TTL: 300},
+			libdns: libdns.RR{Name: "hash._smimecert", TTL: 300 * time.Second, Type: "SMIMEA", Data: "3 1 1 ABCDEF"},
+		},
+		{
+			name:   "SOA",
+			inwx:   nameserverRecord{Name: "example.com", Type: "SOA", Content: "ns.inwx.de hostmaster.inwx.de 2024010101 10800 3600 604800 3600", TTL: 86400},
+			libdns: libdns.RR{Name: "@", TTL: 86400 * time.Second, Type: "SOA", Data: "ns.inwx.de hostmaster.inwx.de 2024010101 10800 3600 604800 3600"},
+		},
+		{
+			name:   "SRV",
+			inwx:   nameserverRecord{Name: "_sip._tcp.example.com", Type: "SRV", Content: "5 5060 sip.example.com", TTL: 300, Priority: 10},
+			libdns: libdns.SRV{Service: "sip", Transport: "tcp", Name: "@", TTL: 300 * time.Second, Priority: 10, Weight: 5, Port: 5060, Target: "sip.example.com"},
+		},
+		{
+			name:   "SSHFP",
+			inwx:   nameserverRecord{Name: "host.example.com", Type: "SSHFP", Content: "4 2 ABCDEF", TTL: 300},
+			libdns: libdns.RR{Name: "host", TTL: 300 * time.Second, Type: "SSHFP", Data: "4 2 ABCDEF"},
+		},
+		{
+			name:   "SVCB",
+			inwx:   nameserverRecord{Name: "_dns.example.com", Type: "SVCB", Content: "dns.example.net", TTL: 300},
+			libdns: libdns.ServiceBinding{Scheme: "dns", Name: "@", TTL: 300 * time.Second, Target: "dns.example.net", Params: libdns.SvcParams{}},
+		},
+		{
+			name:   "TLSA",
+			inwx:   nameserverRecord{Name: "_443._tcp.example.com", Type: "TLSA", Content: "3 1 1 ABCDEF", TTL: 300},
+			libdns: libdns.RR{Name: "_443._tcp", TTL: 300 * time.Second, Type: "TLSA", Data: "3 1 1 ABCDEF"},
+		},
+		{
+			name:   "TXT",
+			inwx:   nameserverRecord{Name: "example.com", Type: "TXT", Content: "v=spf1 -all", TTL: 300},
+			libdns: libdns.TXT{Name: "@", TTL: 300 * time.Second, Text: "v=spf1 -all"},
+		},
+		{
+			name:   "URL",
+			inwx:   nameserverRecord{Name: "www.example.com", Type: "URL", Content: "https://example.net", TTL: 300, urlRedirect: urlRedirect{RedirectType: "HEADER302", Append: true}},
+			libdns: URL{Name: "www", TTL: 300 * time.Second, Target: "https://example.net", RedirectType: RedirectTemporary, AppendPath: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			record := libdnsRecord(test.inwx, "example.com.", 0)
+
+			if !reflect.DeepEqual(record, test.libdns) {
+				t.Errorf("expected libdns record %#v, got %#v", test.libdns, record)
+			}
+
+			inwx, err := inwxRecord(test.libdns)
+
+			if err != nil {
+				t.Fatalf("converting %#v to INWX: %v", test.libdns, err)
+			}
+
+			expected := test.inwx
+			expected.Name = test.libdns.RR().Name
+
+			if inwx != expected {
+				t.Errorf("expected INWX record %+v, got %+v", expected, inwx)
+			}
+		})
+	}
+}
+
+func TestRecordConversion_NormalizesCAA(t *testing.T) {
+	record := libdnsRecord(nameserverRecord{Name: "example.com", Type: "CAA", Content: "0 iodef mailto:security@example.com", TTL: 300}, "example.com.", 0)
+
+	expected := libdns.CAA{Name: "@", TTL: 300 * time.Second, Tag: "iodef", Value: "mailto:security@example.com"}
+
+	if !reflect.DeepEqual(record, expected) {
+		t.Errorf("expected %#v, got %#v", expected, record)
+	}
+}
+
+func TestRecordConversion_ServiceBindingWithPriorityInContent(t *testing.T) {
+	record := libdnsRecord(nameserverRecord{Name: "example.com", Type: "HTTPS", Content: "1 . alpn=h2", TTL: 300}, "example.com.", 0)
+
+	expected := libdns.ServiceBinding{Scheme: "https", Name: "@", TTL: 300 * time.Second, Priority: 1, Target: ".", Params: libdns.SvcParams{"alpn": {"h2"}}}
+
+	if !reflect.DeepEqual(record, expected) {
+		t.Errorf("expected %#v, got %#v", expected, record)
+	}
+}
+
+func TestRecordConversion_FrameFromRR(t *testing.T) {
+	record, err := inwxRecord(libdns.RR{Name: "www", Type: "FRAME", Data: "https://example.net"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record.RedirectType != "FRAME" {
+		t.Errorf("expected redirect type FRAME, got %q", record.RedirectType)
+	}
+}
+
+func TestRecordConversion_RejectsInvalidData(t *testing.T) {
+	records := []libdns.Record{
+		libdns.RR{Name: "@", Type: "MX", Data: "mx.example.com"},
+		libdns.RR{Name: "@", Type: "SRV", Data: "10 5 sip.example.com"},
+		libdns.RR{Name: "@", Type: "CAA", Data: "0 issue"},
+		libdns.RR{Name: "@", Type: "TLSA", Data: "3 one 1 ABCDEF"},
+		libdns.RR{Name: "@", Type: "SSHFP", Data: "4 2"},
+		libdns.RR{Name: "@", Type: "HINFO", Data: `"Intel Linux`},
+		libdns.RR{Name: "@", Type: "HTTPS", Data: "."},
+		libdns.RR{Name: "@", Type: "HTTPS", Data: "high . alpn=h2"},
+	}
+
+	for _, record := range records {
+		if _, err := inwxRecord(record); err == nil {
+			t.Errorf("expected an error for %s record %q", record.RR().Type, record.RR().Data)
+		}
+	}
+}
+
+func TestRecordConversion_KeepsInvalidStoredRecords(t *testing.T) {
+	records := []nameserverRecord{
+		{ID: "1", Name: "example.com", Type: "DS", Content: "12345 13 SHA256 ABCDEF", TTL: 300},
+		{ID: "2", Name: "example.com", Type: "MX", Content: "", TTL: 300},
+		{ID: "3", Name: "example.com", Type: "CAA", Content: "0 issue", TTL: 300},
+	}
+
+	for _, invalid := range records {
+		record := libdnsRecord(invalid, "example.com.", 1000)
+		expected := RR{
+			Name:         "@",
+			Type:         invalid.Type,
+			Data:         invalid.Content,
+			TTL:          300 * time.Second,
+			ProviderData: ProviderData{ID: invalid.ID, RoID: 1000},
+		}
+
+		if record != expected {
+			t.Errorf("expected INWX record %+v to be kept as %#v, got %#v", invalid, expected, record)
+		}
+	}
+}
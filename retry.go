@@ -0,0 +1,161 @@
+package inwx
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// RetryPolicy configures how calls to the INWX API are retried after transient failures. The zero
+// value retries with the defaults described for each field.
+type RetryPolicy struct {
+	// Maximum number of attempts per call, including the first one. It defaults to 3. Set it to 1
+	// to disable retries.
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// Delay before the first retry, which is doubled for every further retry. It defaults to
+	// 500 milliseconds. The actual delay is randomized between zero and the computed delay.
+	BaseDelay time.Duration `json:"base_delay,omitempty"`
+
+	// Upper bound of the delay between two attempts. It defaults to 10 seconds.
+	MaxDelay time.Duration `json:"max_delay,omitempty"`
+
+	// INWX result codes after which a call is retried. It defaults to 2400 (command failed),
+	// 2500 (command failed; server closing connection) and 2502 (session limit exceeded).
+	RetryableCodes []int `json:"retryable_codes,omitempty"`
+}
+
+var defaultRetryableCodes = []int{2400, 2500, 2502}
+
+// Methods which must not be repeated if it is unknown whether the previous attempt has been
+// executed, because repeating them would create duplicates.
+var nonIdempotentMethods = []string{
+	"nameserver.create",
+	"nameserver.createRecord",
+	"dnssec.keyRollover",
+}
+
+// Result codes with which INWX rejects methods whose effect is already in place, e.g. deleting a
+// record which does not exist anymore. If an earlier attempt of the call may have been executed,
+// such a result means that it has been, so that the call succeeded.
+var alreadyExecutedCodes = map[string]int{
+	"nameserver.delete":       2303,
+	"nameserver.deleteRecord": 2303,
+	"dnssec.enableDNSSEC":     2302,
+}
+
+// withDefaults returns a copy of the policy in which all unset fields are set to their defaults.
+func (r RetryPolicy) withDefaults() RetryPolicy {
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = 3
+	}
+
+	if r.BaseDelay <= 0 {
+		r.BaseDelay = 500 * time.Millisecond
+	}
+
+	if r.MaxDelay <= 0 {
+		r.MaxDelay = 10 * time.Second
+	}
+
+	if r.RetryableCodes == nil {
+		r.RetryableCodes = defaultRetryableCodes
+	}
+
+	return r
+}
+
+// delay returns the randomized delay before the given retry, starting at 1.
+func (r RetryPolicy) delay(retry int) time.Duration {
+	delay := r.MaxDelay
+
+	// The maximum is shifted instead of the base delay, so that doubling cannot overflow.
+	if shift := retry - 1; shift < 63 && r.BaseDelay <= r.MaxDelay>>shift {
+		delay = r.BaseDelay << shift
+	}
+
+	return rand.N(delay + 1)
+}
+
+// isRetryable reports whether the method can be called again after it failed with the error.
+func (r RetryPolicy) isRetryable(method string, err error) bool {
+	var apiError *APIError
+
+	if errors.As(err, &apiError) {
+		// INWX explicitly reported that the command failed, so it is safe to repeat any method.
+		return slices.Contains(r.RetryableCodes, apiError.Code)
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if !mayHaveBeenExecuted(err) {
+		// The request has not been sent or has been rejected, so it is safe to repeat any method.
+		return true
+	}
+
+	if slices.Contains(nonIdempotentMethods, method) {
+		return false
+	}
+
+	var httpError *HTTPError
+
+	if errors.As(err, &httpError) {
+		return httpError.StatusCode >= 500
+	}
+
+	var netError net.Error
+
+	return errors.As(err, &netError)
+}
+
+// mayHaveBeenExecuted reports whether INWX may have executed a method although the call failed with
+// the error.
+func mayHaveBeenExecuted(err error) bool {
+	var apiError *APIError
+
+	if errors.As(err, &apiError) {
+		// INWX explicitly reported that the command failed.
+		return false
+	}
+
+	var opError *net.OpError
+
+	if errors.As(err, &opError) && opError.Op == "dial" {
+		// The request has not been sent.
+		return false
+	}
+
+	var httpError *HTTPError
+
+	// A request with status 429 has been rejected.
+	return !errors.As(err, &httpError) || httpError.StatusCode != http.StatusTooManyRequests
+}
+
+// isAlreadyExecuted reports whether the error means that the effect of the method is already in
+// place.
+func isAlreadyExecuted(method string, err error) bool {
+	var apiError *APIError
+
+	code, ok := alreadyExecutedCodes[method]
+
+	return ok && errors.As(err, &apiError) && apiError.Code == code
+}
+
+// wait blocks for the delay before the given retry or until the context is done.
+func (r RetryPolicy) wait(ctx context.Context, retry int) error {
+	timer := time.NewTimer(r.delay(retry))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,16 @@
+package inwx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Second, MaxDelay: time.Hour, MaxAttempts: 40}.withDefaults()
+
+	for retry := 1; retry < policy.MaxAttempts; retry++ {
+		if delay := policy.delay(retry); delay < 0 || delay > policy.MaxDelay {
+			t.Fatalf("expected delay of retry %d between 0 and %s, got %s", retry, policy.MaxDelay, delay)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package inwx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SOA contains the values of the SOA record of a zone.
+type SOA struct {
+	// Host name of the primary nameserver.
+	Nameserver string
+
+	// Email address of the hostmaster, e.g. hostmaster@example.com.
+	Email string
+
+	// Serial number of the zone. It is only used by UpdateSOA with [SerialExplicit].
+	Serial uint32
+
+	// Interval after which secondary nameservers check the serial for changes.
+	Refresh time.Duration
+
+	// Interval after which secondary nameservers retry a failed refresh.
+	Retry time.Duration
+
+	// Duration after which secondary nameservers stop answering if refreshing keeps failing.
+	Expire time.Duration
+
+	// Duration for which negative answers are cached.
+	MinimumTTL time.Duration
+
+	// TTL of the SOA record itself. If it is zero, UpdateSOA keeps the current TTL.
+	TTL time.Duration
+}
+
+// SerialStrategy determines how UpdateSOA sets the serial of the zone.
+type SerialStrategy int
+
+const (
+	// SerialDate sets the serial to the current date in the format YYYYMMDDnn, as INWX does. If
+	// the current serial is already at or beyond the date, it is incremented by one instead.
+	SerialDate SerialStrategy = iota
+
+	// SerialIncrement increments the current serial by one, wrapping around to 0 after the largest
+	// serial as defined by RFC 1982.
+	SerialIncrement
+
+	// SerialExplicit sets the serial of the given SOA, which must be greater than the current serial
+	// in serial number arithmetic as defined by RFC 1982.
+	SerialExplicit
+)
+
+// GetSOA returns the values of the SOA record of the zone.
+func (p *Provider) GetSOA(ctx context.Context, zone string) (_ SOA, err error) {
+	ctx, span := p.startSpan(ctx, "GetSOA", zone)
+	defer func() { endSpan(span, err) }()
+
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		return SOA{}, err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	info, err := client.getRecords(ctx, getDomain(zone))
+
+	if err != nil {
+		return SOA{}, err
+	}
+
+	_, soa, err := findSOA(info, zone)
+
+	return soa, err
+}
+
+// UpdateSOA sets the values of the SOA record of the zone. The serial is set according to the
+// strategy. The values are validated before they are sent to INWX: all intervals must be positive
+// whole seconds, the retry interval must be shorter than the refresh interval and the expiry must
+// be longer than both together.
+func (p *Provider) UpdateSOA(ctx context.Context, zone string, soa SOA, strategy SerialStrategy) (err error) {
+	ctx, span := p.startSpan(ctx, "UpdateSOA", zone)
+	defer func() { endSpan(span, err) }()
+
+	err = soa.validate()
+
+	if err != nil {
+		return fmt.Errorf("invalid SOA of zone %s: %w", zone, err)
+	}
+
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	info, err := client.getRecords(ctx, getDomain(zone))
+
+	if err != nil {
+		return err
+	}
+
+	err = checkMasterZone(info, zone)
+
+	if err != nil {
+		return err
+	}
+
+	record, current, err := findSOA(info, zone)
+
+	if err != nil {
+		return err
+	}
+
+	soa.Serial, err = nextSerial(current.Serial, soa.Serial, strategy, time.Now())
+
+	if err != nil {
+		return fmt.Errorf("invalid SOA of zone %s: %w", zone, err)
+	}
+
+	if soa.TTL == 0 {
+		soa.TTL = current.TTL
+	}
+
+	record.Name = "@"
+	record.Content = soa.content()
+	record.TTL = int(soa.TTL.Seconds())
+
+	return client.updateRecord(ctx, record)
+}
+
+// findSOA returns the SOA record of the zone and its parsed values.
+func findSOA(info *nameserverInfoResponse, zone string) (nameserverRecord, SOA, error) {
+	for _, record := range info.Records {
+		if record.Type != "SOA" {
+			continue
+		}
+
+		soa, err := parseSOA(record)
+
+		if err != nil {
+			return nameserverRecord{}, SOA{}, fmt.Errorf("parsing SOA of zone %s: %w", zone, err)
+		}
+
+		return record, soa, nil
+	}
+
+	return nameserverRecord{}, SOA{}, fmt.Errorf("SOA of zone %s: %w", zone, ErrObjectNotFound)
+}
+
+func parseSOA(record nameserverRecord) (SOA, error) {
+	fields := strings.Fields(record.Content)
+
+	if len(fields) != 7 {
+		return SOA{}, fmt.Errorf("expected 7 fields in %q", record.Content)
+	}
+
+	var numbers [5]uint32
+
+	for i := range numbers {
+		number, err := strconv.ParseUint(fields[i+2], 10, 32)
+
+		if err != nil {
+			return SOA{}, fmt.Errorf("expected field %d of %q to be numeric", i+3, record.Content)
+		}
+
+		numbers[i] = uint32(number)
+	}
+
+	return SOA{
+		Nameserver: fields[0],
+		Email:      rnameToEmail(fields[1]),
+		Serial:     numbers[0],
+		Refresh:    time.Duration(numbers[1]) * time.Second,
+		Retry:      time.Duration(numbers[2]) * time.Second,
+		Expire:     time.Duration(numbers[3]) * time.Second,
+		MinimumTTL: time.Duration(numbers[4]) * time.Second,
+		TTL:        time.Duration(record.TTL) * time.Second,
+	}, nil
+}
+
+// content returns the SOA in the format of the content of INWX records.
+func (s SOA) content() string {
+	return fmt.Sprintf("%s %s %d %d %d %d %d",
+		s.Nameserver,
+		emailToRName(s.Email),
+		s.Serial,
+		int(s.Refresh.Seconds()),
+		int(s.Retry.Seconds()),
+		int(s.Expire.Seconds()),
+		int(s.MinimumTTL.Seconds()),
+	)
+}
+
+func (s SOA) validate() error {
+	var errs []error
+
+	if s.Nameserver == "" || strings.ContainsAny(s.Nameserver, " \t") {
+		errs = append(errs, fmt.Errorf("invalid nameserver %q", s.Nameserver))
+	}
+
+	local, domain, ok := strings.Cut(s.Email, "@")
+
+	if !ok || local == "" || domain == "" || strings.ContainsAny(s.Email, " \t") || strings.Contains(domain, "@") {
+		errs = append(errs, fmt.Errorf("invalid email address %q", s.Email))
+	}
+
+	intervals := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"refresh", s.Refresh},
+		{"retry", s.Retry},
+		{"expire", s.Expire},
+		{"minimum TTL", s.MinimumTTL},
+	}
+
+	for _, interval := range intervals {
+		if interval.value <= 0 || interval.value%time.Second != 0 || interval.value.Seconds() > 1<<31-1 {
+			errs = append(errs, fmt.Errorf("%s must be a positive number of seconds, got %s", interval.name, interval.value))
+		}
+	}
+
+	if s.TTL < 0 || s.TTL%time.Second != 0 {
+		errs = append(errs, fmt.Errorf("TTL must be a number of seconds, got %s", s.TTL))
+	}
+
+	if s.Retry >= s.Refresh {
+		errs = append(errs, fmt.Errorf("retry %s must be shorter than refresh %s", s.Retry, s.Refresh))
+	}
+
+	if s.Expire <= s.Refresh+s.Retry {
+		errs = append(errs, fmt.Errorf("expire %s must be longer than refresh and retry together", s.Expire))
+	}
+
+	return errors.Join(errs...)
+}
+
+// nextSerial returns the serial which follows the current serial according to the strategy. Serials
+// are incremented and compared in serial number arithmetic as defined by RFC 1982, so that
+// secondary nameservers recognize the new serial as greater even after it wrapped around.
+func nextSerial(current uint32, explicit uint32, strategy SerialStrategy, now time.Time) (uint32, error) {
+	switch strategy {
+	case SerialDate:
+		year, month, day := now.UTC().Date()
+		date := uint32(year*1000000 + int(month)*10000 + day*100)
+
+		if !isSerialGreater(date, current) {
+			return current + 1, nil
+		}
+
+		return date, nil
+	case SerialIncrement:
+		return current + 1, nil
+	case SerialExplicit:
+		if !isSerialGreater(explicit, current) {
+			return 0, fmt.Errorf("serial %d must be greater than the current serial %d in serial number arithmetic", explicit, current)
+		}
+
+		return explicit, nil
+	}
+
+	return 0, fmt.Errorf("unknown serial strategy %d", strategy)
+}
+
+// isSerialGreater reports whether serial a is greater than serial b as defined by RFC 1982. The
+// subtraction of uint32 values wraps around, so a is greater if it lies less than 2^31 after b.
+func isSerialGreater(a uint32, b uint32) bool {
+	return a != b && a-b < 1<<31
+}
+
+// emailToRName converts an email address into the mailbox name of an SOA record, in which the @
+// is replaced by a dot and dots in the local part are escaped.
+func emailToRName(email string) string {
+	local, domain, _ := strings.Cut(email, "@")
+
+	return strings.ReplaceAll(local, ".", `\.`) + "." + domain
+}
+
+// rnameToEmail converts the mailbox name of an SOA record into an email address.
+func rnameToEmail(rname string) string {
+	for i := 0; i < len(rname); i++ {
+		switch rname[i] {
+		case '\\':
+			i++
+		case '.':
+			return strings.ReplaceAll(rname[:i], `\.`, ".") + "@" + strings.TrimSuffix(rname[i+1:], ".")
+		}
+	}
+
+	return rname
+}
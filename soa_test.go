@@ -0,0 +1,106 @@
+package inwx
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNextSerial(t *testing.T) {
+	now := time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		current  uint32
+		explicit uint32
+		strategy SerialStrategy
+		expected uint32
+	}{
+		{current: 2024010101, strategy: SerialDate, expected: 2024030500},
+		{current: 2024030500, strategy: SerialDate, expected: 2024030501},
+		{current: 2024030599, strategy: SerialDate, expected: 2024030600},
+		{current: 3000000000, strategy: SerialDate, expected: 3000000001},
+		{current: 5, strategy: SerialDate, expected: 2024030500},
+		{current: 7, strategy: SerialIncrement, expected: 8},
+		{current: math.MaxUint32, strategy: SerialIncrement, expected: 0},
+		{current: 7, explicit: 42, strategy: SerialExplicit, expected: 42},
+		{current: math.MaxUint32, explicit: 5, strategy: SerialExplicit, expected: 5},
+	}
+
+	for _, test := range tests {
+		serial, err := nextSerial(test.current, test.explicit, test.strategy, now)
+
+		if err != nil {
+			t.Errorf("unexpected error for %+v: %v", test, err)
+		} else if serial != test.expected {
+			t.Errorf("expected serial %d for %+v, got %d", test.expected, test, serial)
+		}
+	}
+
+	invalid := []struct {
+		current  uint32
+		explicit uint32
+	}{
+		{current: 42, explicit: 42},
+		{current: 42, explicit: 41},
+		{current: 5, explicit: math.MaxUint32},
+		{current: 0, explicit: 1 << 31},
+	}
+
+	for _, test := range invalid {
+		if _, err := nextSerial(test.current, test.explicit, SerialExplicit, now); err == nil {
+			t.Errorf("expected an error for explicit serial %d, which is not greater than %d", test.explicit, test.current)
+		}
+	}
+}
+
+func TestSOAEmail(t *testing.T) {
+	emails := map[string]string{
+		"hostmaster@example.com":   "hostmaster.example.com",
+		"first.last@example.com":   `first\.last.example.com`,
+		"dns-admin@sub.example.de": "dns-admin.sub.example.de",
+	}
+
+	for email, rname := range emails {
+		if converted := emailToRName(email); converted != rname {
+			t.Errorf("expected %q for %q, got %q", rname, email, converted)
+		}
+
+		if converted := rnameToEmail(rname); converted != email {
+			t.Errorf("expected %q for %q, got %q", email, rname, converted)
+		}
+	}
+}
+
+func TestSOAValidate(t *testing.T) {
+	valid := SOA{
+		Nameserver: "ns.inwx.de",
+		Email:      "hostmaster@example.com",
+		Refresh:    time.Hour,
+		Retry:      10 * time.Minute,
+		Expire:     7 * 24 * time.Hour,
+		MinimumTTL: 5 * time.Minute,
+	}
+
+	if err := valid.validate(); err != nil {
+		t.Fatalf("expected %+v to be valid, got %v", valid, err)
+	}
+
+	invalid := []func(*SOA){
+		func(s *SOA) { s.Nameserver = "" },
+		func(s *SOA) { s.Email = "hostmaster.example.com" },
+		func(s *SOA) { s.Refresh = 0 },
+		func(s *SOA) { s.MinimumTTL = 1500 * time.Millisecond },
+		func(s *SOA) { s.Retry = 2 * time.Hour },
+		func(s *SOA) { s.Expire = time.Hour },
+		func(s *SOA) { s.TTL = -time.Second },
+	}
+
+	for i, modify := range invalid {
+		soa := valid
+		modify(&soa)
+
+		if err := soa.validate(); err == nil {
+			t.Errorf("expected an error for SOA %d: %+v", i, soa)
+		}
+	}
+}
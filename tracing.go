@@ -0,0 +1,60 @@
+package inwx
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/libdns/inwx"
+
+// zoneContextKey is the context key under which the zone of the current operation is stored, so
+// that spans of JSON-RPC calls can be annotated with it.
+type zoneContextKey struct{}
+
+// startSpan starts the span of a provider operation on the zone.
+func (p *Provider) startSpan(ctx context.Context, operation string, zone string) (context.Context, trace.Span) {
+	var attributes []attribute.KeyValue
+
+	if zone != "" {
+		ctx = context.WithValue(ctx, zoneContextKey{}, zone)
+		attributes = append(attributes, attribute.String("inwx.zone", zone))
+	}
+
+	return p.getTracer().Start(ctx, "inwx."+operation, trace.WithAttributes(attributes...))
+}
+
+func (p *Provider) getTracer() trace.Tracer {
+	if p.TracerProvider != nil {
+		return p.TracerProvider.Tracer(tracerName)
+	}
+
+	return otel.GetTracerProvider().Tracer(tracerName)
+}
+
+// startCallSpan starts the span of a JSON-RPC call.
+func (c *client) startCallSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	attributes := []attribute.KeyValue{
+		attribute.String("rpc.system", "jsonrpc"),
+		attribute.String("rpc.method", method),
+	}
+
+	if zone, ok := ctx.Value(zoneContextKey{}).(string); ok {
+		attributes = append(attributes, attribute.String("inwx.zone", zone))
+	}
+
+	return c.tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+}
+
+// endSpan records the error, if any, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,150 @@
+package inwx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+type changeKind int
+
+const (
+	changeCreate changeKind = iota
+	changeUpdate
+	changeDelete
+)
+
+// change is a modification of a record that has been applied to a zone.
+type change struct {
+	kind changeKind
+
+	// The created record, or the state of the updated or deleted record before the change.
+	record nameserverRecord
+}
+
+// transaction applies changes to a zone and records them, so that they can be rolled back if a
+// later change fails.
+type transaction struct {
+	client  *client
+	domain  string
+	atomic  bool
+	changes []change
+}
+
+// Maximum duration of a rollback, which is not canceled together with the failed operation.
+const rollbackTimeout = time.Minute
+
+func newTransaction(client *client, domain string, atomic bool) *transaction {
+	return &transaction{client: client, domain: domain, atomic: atomic}
+}
+
+func (t *transaction) createRecord(ctx context.Context, record nameserverRecord) (string, error) {
+	id, err := t.client.createRecord(ctx, record, t.domain)
+
+	if err != nil {
+		return "", err
+	}
+
+	t.changes = append(t.changes, change{kind: changeCreate, record: storedRecord(record, id)})
+
+	return id, nil
+}
+
+// updateRecords sets the values of the record on all previous records at once.
+func (t *transaction) updateRecords(ctx context.Context, record nameserverRecord, previous []nameserverRecord) error {
+	ids := make([]string, 0, len(previous))
+
+	for _, previous := range previous {
+		ids = append(ids, previous.ID)
+	}
+
+	err := t.client.updateRecords(ctx, ids, record)
+
+	if err != nil {
+		return err
+	}
+
+	for _, previous := range previous {
+		t.changes = append(t.changes, change{kind: changeUpdate, record: t.relative(previous)})
+	}
+
+	return nil
+}
+
+// deleteRecords deletes all records at once.
+func (t *transaction) deleteRecords(ctx context.Context, records []nameserverRecord) error {
+	ids := make([]string, 0, len(records))
+
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+
+	err := t.client.deleteRecords(ctx, ids)
+
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		t.changes = append(t.changes, change{kind: changeDelete, record: t.relative(record)})
+	}
+
+	return nil
+}
+
+// relative returns a copy of the record with its name relative to the zone, because INWX returns
+// fully qualified names but expects names relative to the zone when records are created or
+// updated.
+func (t *transaction) relative(record nameserverRecord) nameserverRecord {
+	record.Name = libdns.RelativeName(record.Name, t.domain)
+
+	return record
+}
+
+// complete finishes the transaction with the error of the operation. If the operation failed and
+// the transaction is atomic, all changes are rolled back. The returned error wraps
+// [ErrRolledBack] if the rollback succeeded, and contains the errors of the rollback otherwise. It
+// is returned unchanged if no change has been applied.
+func (t *transaction) complete(ctx context.Context, err error) error {
+	if err == nil || !t.atomic || len(t.changes) == 0 {
+		return err
+	}
+
+	rollbackErr := t.rollback(ctx)
+
+	if rollbackErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to roll back changes: %w", rollbackErr))
+	}
+
+	return libdns.AtomicErr(fmt.Errorf("%w; %w", err, ErrRolledBack))
+}
+
+// rollback reverts the changes in reverse order. It continues after failures and returns all
+// errors that occurred.
+func (t *transaction) rollback(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	var errs []error
+
+	for i := len(t.changes) - 1; i >= 0; i-- {
+		change := t.changes[i]
+
+		switch change.kind {
+		case changeCreate:
+			errs = append(errs, t.client.deleteRecord(ctx, change.record))
+		case changeUpdate:
+			errs = append(errs, t.client.updateRecord(ctx, change.record))
+		case changeDelete:
+			_, err := t.client.createRecord(ctx, change.record, t.domain)
+			errs = append(errs, err)
+		}
+	}
+
+	t.changes = nil
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,386 @@
+package inwx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/libdns/libdns"
+)
+
+// ZoneType is the type of a zone at INWX.
+type ZoneType string
+
+const (
+	// ZoneTypeMaster is a zone whose records are managed at INWX.
+	ZoneTypeMaster ZoneType = "MASTER"
+
+	// ZoneTypeSlave is a zone which INWX transfers from master nameservers.
+	ZoneTypeSlave ZoneType = "SLAVE"
+)
+
+// ZoneOptions configures a zone created with CreateZone.
+type ZoneOptions struct {
+	// Type of the zone. It defaults to [ZoneTypeMaster].
+	Type ZoneType
+
+	// Nameservers of the zone. If it is empty, INWX uses its default nameservers.
+	Nameservers []string
+
+	// IP addresses of the master nameservers from which a slave zone is transferred. They are
+	// required for slave zones.
+	MasterIPs []string
+
+	// Records which are added to the zone after it has been created. They cannot be set for slave
+	// zones.
+	Records []libdns.Record
+
+	// Email address of the hostmaster, which is set in the SOA record. If it is empty, INWX uses
+	// the email address of the account.
+	SOAEmail string
+
+	// IP address or URL for which INWX creates the default web records of the zone. No web records
+	// are created if it is empty.
+	Web string
+
+	// Host name of the mail server for which INWX creates the default mail records of the zone. No
+	// mail records are created if it is empty.
+	Mail string
+}
+
+// CreateZone creates the zone at INWX and adds the records of the options to it. If the records
+// cannot be added, the zone is deleted again.
+func (p *Provider) CreateZone(ctx context.Context, zone string, options ZoneOptions) (err error) {
+	ctx, span := p.startSpan(ctx, "CreateZone", zone)
+	defer func() { endSpan(span, err) }()
+
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	zoneType := options.Type
+
+	if zoneType == "" {
+		zoneType = ZoneTypeMaster
+	}
+
+	if zoneType == ZoneTypeSlave && len(options.MasterIPs) == 0 {
+		return fmt.Errorf("slave zone %s requires the IP addresses of its master nameservers", zone)
+	}
+
+	if zoneType == ZoneTypeSlave && len(options.Records) > 0 {
+		return fmt.Errorf("cannot add records to zone %s: %w", zone, ErrSlaveZone)
+	}
+
+	err = client.createNameserver(ctx, nameserverCreateRequest{
+		Domain:   getDomain(zone),
+		Type:     string(zoneType),
+		NS:       options.Nameservers,
+		MasterIP: strings.Join(options.MasterIPs, ","),
+		SOAEmail: options.SOAEmail,
+		Web:      options.Web,
+		Mail:     options.Mail,
+	})
+
+	if err != nil || len(options.Records) == 0 {
+		return err
+	}
+
+	_, err = p.AppendRecords(ctx, zone, options.Records)
+
+	if err != nil {
+		deleteErr := client.deleteNameserver(context.WithoutCancel(ctx), getDomain(zone))
+
+		if deleteErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to delete zone %s again: %w", zone, deleteErr))
+		}
+
+		return fmt.Errorf("%w; zone %s has been deleted again", err, zone)
+	}
+
+	return nil
+}
+
+// DeleteZone deletes the zone and all its records at INWX.
+func (p *Provider) DeleteZone(ctx context.Context, zone string) (err error) {
+	ctx, span := p.startSpan(ctx, "DeleteZone", zone)
+	defer func() { endSpan(span, err) }()
+
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	return client.deleteNameserver(ctx, getDomain(zone))
+}
+
+// SlaveZone is a zone which INWX transfers from master nameservers.
+type SlaveZone struct {
+	// Name of the zone.
+	Name string
+
+	// IP addresses of the master nameservers.
+	MasterIPs []string
+}
+
+// ListSlaveZones lists the slave zones of the INWX account with their master nameservers.
+func (p *Provider) ListSlaveZones(ctx context.Context) (_ []SlaveZone, err error) {
+	ctx, span := p.startSpan(ctx, "ListSlaveZones", "")
+	defer func() { endSpan(span, err) }()
+
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	domains, err := client.listNameservers(ctx, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var zones []SlaveZone
+
+	for _, domain := range domains {
+		if domain.Type != string(ZoneTypeSlave) {
+			continue
+		}
+
+		zones = append(zones, SlaveZone{
+			Name:      domain.Domain,
+			MasterIPs: splitMasterIPs(domain.MasterIP),
+		})
+	}
+
+	return zones, nil
+}
+
+// UpdateSlaveZone sets the IP addresses of the master nameservers of the slave zone.
+func (p *Provider) UpdateSlaveZone(ctx context.Context, zone string, masterIPs []string) (err error) {
+	ctx, span := p.startSpan(ctx, "UpdateSlaveZone", zone)
+	defer func() { endSpan(span, err) }()
+
+	if len(masterIPs) == 0 {
+		return fmt.Errorf("slave zone %s requires the IP addresses of its master nameservers", zone)
+	}
+
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	return client.updateNameserver(ctx, nameserverUpdateRequest{
+		Domain:   getDomain(zone),
+		MasterIP: strings.Join(masterIPs, ","),
+	})
+}
+
+// TransferZone asks INWX to transfer the slave zone from its master nameservers now, instead of
+// waiting for the next refresh.
+func (p *Provider) TransferZone(ctx context.Context, zone string) (err error) {
+	ctx, span := p.startSpan(ctx, "TransferZone", zone)
+	defer func() { endSpan(span, err) }()
+
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	return client.transferNameserver(ctx, getDomain(zone))
+}
+
+// splitMasterIPs splits the comma-separated master IP addresses returned by INWX.
+func splitMasterIPs(masterIP string) []string {
+	var ips []string
+
+	for _, ip := range strings.Split(masterIP, ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+
+	return ips
+}
+
+// zoneRoID returns the roId of the zone, which is looked up in the list of zones, because INWX does
+// not return it when records are created.
+func zoneRoID(ctx context.Context, client *client, zone string) (int, error) {
+	domains, err := client.listNameservers(ctx, getDomain(zone))
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, domain := range domains {
+		if strings.EqualFold(domain.Domain, getDomain(zone)) {
+			return domain.RoID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("zone %s: %w", zone, ErrObjectNotFound)
+}
+
+// checkMasterZone returns an error wrapping [ErrSlaveZone] if the zone described by the info is a
+// slave zone.
+func checkMasterZone(info *nameserverInfoResponse, zone string) error {
+	if info.Type == string(ZoneTypeSlave) {
+		return fmt.Errorf("zone %s: %w", zone, ErrSlaveZone)
+	}
+
+	return nil
+}
+
+// slaveZoneError returns an error wrapping [ErrSlaveZone] if INWX rejected a change of the zone
+// because it is a slave zone, and the error unchanged otherwise. It is only called after a change
+// failed, so that changes of master zones do not need an additional request.
+func slaveZoneError(ctx context.Context, client *client, zone string, err error) error {
+	var apiError *APIError
+
+	if !errors.As(err, &apiError) {
+		return err
+	}
+
+	info, infoErr := client.getRecords(ctx, getDomain(zone))
+
+	if infoErr != nil {
+		return err
+	}
+
+	if slaveErr := checkMasterZone(info, zone); slaveErr != nil {
+		return fmt.Errorf("%w: %w", slaveErr, err)
+	}
+
+	return err
+}
+
+// ZoneDetails contains the metadata of a zone at INWX.
+type ZoneDetails struct {
+	// Name of the zone.
+	Name string
+
+	// ID of the zone at INWX.
+	RoID int
+
+	// Type of the zone.
+	Type ZoneType
+
+	// IP addresses of the master nameservers of a slave zone.
+	MasterIPs []string
+
+	// Number of records in the zone. It is only set if the records have been fetched.
+	RecordCount int
+
+	// DNSSEC status of the zone as reported by INWX, or an empty string if DNSSEC is not enabled.
+	DNSSECStatus string
+
+	// Nameservers of the zone, which are taken from the NS records at the apex. They are only set if
+	// the records have been fetched.
+	Nameservers []string
+}
+
+// ZoneDetailsOptions configures which details ListZoneDetails fetches.
+type ZoneDetailsOptions struct {
+	// Whether the records of every zone are fetched to set the record count and nameservers of the
+	// zone, which takes one request per zone.
+	FetchRecords bool
+}
+
+// ListZoneDetails lists the zones of the INWX account whose name matches the pattern, in which *
+// matches any characters, together with their metadata. All zones are listed if the pattern is
+// empty. The pattern is evaluated by INWX, so that only matching zones are transferred.
+//
+// Besides listing the zones, the DNSSEC status of all zones is fetched with one request, and the
+// records of every zone with one request per zone if the options ask for them.
+func (p *Provider) ListZoneDetails(ctx context.Context, pattern string, options ZoneDetailsOptions) (_ []ZoneDetails, err error) {
+	ctx, span := p.startSpan(ctx, "ListZoneDetails", "")
+	defer func() { endSpan(span, err) }()
+
+	client, err := p.getClient(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer p.releaseClient(ctx, client)
+
+	domains, err := client.listNameservers(ctx, pattern)
+
+	if err != nil || len(domains) == 0 {
+		return nil, err
+	}
+
+	zones := make([]ZoneDetails, 0, len(domains))
+	names := make([]string, 0, len(domains))
+
+	for _, domain := range domains {
+		details := ZoneDetails{
+			Name:      domain.Domain,
+			RoID:      domain.RoID,
+			Type:      ZoneType(domain.Type),
+			MasterIPs: splitMasterIPs(domain.MasterIP),
+		}
+
+		if options.FetchRecords {
+			info, err := client.getRecords(ctx, domain.Domain)
+
+			if err != nil {
+				return nil, err
+			}
+
+			details.RecordCount = info.Count
+			details.Nameservers = apexNameservers(info.Records, domain.Domain)
+		}
+
+		zones = append(zones, details)
+		names = append(names, domain.Domain)
+	}
+
+	dnssec, err := client.getDNSSECInfo(ctx, names)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range dnssec {
+		index := slices.IndexFunc(zones, func(zone ZoneDetails) bool {
+			return strings.EqualFold(zone.Name, item.Domain)
+		})
+
+		if index >= 0 {
+			zones[index].DNSSECStatus = item.Status
+		}
+	}
+
+	return zones, nil
+}
+
+// apexNameservers returns the targets of the NS records at the apex of the domain.
+func apexNameservers(records []nameserverRecord, domain string) []string {
+	var nameservers []string
+
+	for _, record := range records {
+		if record.Type == "NS" && isSameName(record.Name, "@", domain) {
+			nameservers = append(nameservers, record.Content)
+		}
+	}
+
+	return nameservers
+}